@@ -18,17 +18,32 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 
+	"favorite-colors-mcp/internal/mcp"
+	"favorite-colors-mcp/internal/storage"
 	"favorite-colors-mcp/internal/transport"
 )
 
 func main() {
 	// Parse command line flags
 	var (
-		transportType = flag.String("transport", "stdio", "Transport type: stdio, http, or https")
-		port          = flag.String("port", ":8080", "Port for HTTP/HTTPS transport (e.g., :8080)")
-		certFile      = flag.String("cert", "", "TLS certificate file for HTTPS (required for https transport)")
-		keyFile       = flag.String("key", "", "TLS private key file for HTTPS (required for https transport)")
+		transportType = flag.String("transport", "stdio", "Transport type: stdio, http, https, ws, wss, or unix")
+		port          = flag.String("port", ":8080", "Port for HTTP/HTTPS and WebSocket transports (e.g., :8080)")
+		certFile      = flag.String("cert", "", "TLS certificate file (required for https and wss transports)")
+		keyFile       = flag.String("key", "", "TLS private key file (required for https and wss transports)")
+		storageDSN    = flag.String("storage", "", "Storage driver or DSN: memory, file, kv, or e.g. kv:./data (default: file if -data-dir is set, else memory)")
+		dataDir       = flag.String("data-dir", "", "Directory for persistent storage, used by the file and kv drivers. Favorites of anonymous HTTP and WebSocket sessions are only kept in memory, until the session ends or the server stops")
+		socketPath    = flag.String("socket", "favorite-colors-mcp.sock", "Socket path for the unix transport")
+		socketMode    = flag.String("socket-mode", "0600", "Octal file permissions of the unix transport's socket")
+		socketProto   = flag.String("socket-protocol", transport.SocketProtocolLines, "Protocol on the unix transport's socket: lines (newline-delimited JSON-RPC) or http")
+		socketUIDs    = flag.String("socket-uids", "", "Comma-separated user IDs admitted on the unix transport (default: every user the socket permissions admit)")
+		wsOrigins     = flag.String("ws-origins", "", "Comma-separated origins of web pages allowed to open WebSocket connections, such as https://tools.example.com, or * for any (default: pages of the server's own host)")
+		authTokens    = flag.String("auth-tokens", "", "File of \"token principal [admin]\" lines; requires bearer tokens on HTTP and WebSocket transports and gives each principal its own favorites. Browsers may send WebSocket tokens as a \"bearer.<base64url token>\" subprotocol; the access_token query parameter also works but leaks tokens into logs and history")
+		disableTools  = flag.String("disable-tools", "", "Comma-separated tools to disable for every session, such as clear_colors")
+		adminTools    = flag.String("admin-tools", "", "Comma-separated tools only admin principals may use, such as clear_colors; anonymous clients are not admins")
 		help          = flag.Bool("help", false, "Show help")
 	)
 	flag.Parse()
@@ -50,31 +65,143 @@ func main() {
 		fmt.Println("  favorite-colors-mcp -transport=http                   # HTTP transport (MCP Inspector)")
 		fmt.Println("  favorite-colors-mcp -transport=https -cert=certificates/server.crt -key=certificates/server.key  # HTTPS transport")
 		fmt.Println("  favorite-colors-mcp -transport=http -port=:9000       # HTTP on custom port")
+		fmt.Println("  favorite-colors-mcp -transport=ws                     # WebSocket transport (ws://localhost:8080/mcp)")
+		fmt.Println("  favorite-colors-mcp -transport=unix -socket=/run/colors.sock  # Unix socket for local sidecars")
+		fmt.Println("  favorite-colors-mcp -data-dir=./data                  # Persist favorites across restarts")
+		fmt.Println("  favorite-colors-mcp -storage=kv:./data                # Persist in the embedded key-value store")
+		fmt.Println("  favorite-colors-mcp -disable-tools=clear_colors       # Keep clients from clearing favorites")
+		fmt.Println("  favorite-colors-mcp -transport=http -auth-tokens=tokens.txt -admin-tools=clear_colors  # Only admins clear favorites")
 		fmt.Println()
-		fmt.Println("Available tools: add_color, get_colors, remove_color, clear_colors")
+		fmt.Printf("Storage drivers: %s\n", strings.Join(storage.Drivers(), ", "))
+		fmt.Println()
+		fmt.Println("Available tools: add_color, import_colors, get_colors, remove_color, clear_colors")
 		return
 	}
 
-	var err error
+	if (*transportType == "https" || *transportType == "wss") && (*certFile == "" || *keyFile == "") {
+		log.Fatalf("%s transport requires both -cert and -key flags", strings.ToUpper(*transportType))
+	}
+
+	dsn := storageDSNFromFlags(*storageDSN, *dataDir)
+	backend, err := storage.Open(dsn)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	if name, location := storage.ParseDSN(dsn); location != "" {
+		log.Printf("Persisting favorite colors with the %s driver in %s", name, location)
+	}
+	server := mcp.NewServerWithStorage(backend)
+	if *disableTools != "" {
+		for _, name := range strings.Split(*disableTools, ",") {
+			if err := server.SetToolEnabled(strings.TrimSpace(name), false); err != nil {
+				log.Fatalf("Invalid -disable-tools: %v", err)
+			}
+		}
+		log.Printf("Disabled tools: %s", *disableTools)
+	}
+	if *adminTools != "" {
+		for _, name := range strings.Split(*adminTools, ",") {
+			if err := server.RestrictToolToAdmins(strings.TrimSpace(name)); err != nil {
+				log.Fatalf("Invalid -admin-tools: %v", err)
+			}
+		}
+		log.Printf("Tools restricted to admins: %s", *adminTools)
+	}
+
+	var auth *transport.TokenAuthenticator
+	if *authTokens != "" {
+		auth, err = transport.LoadTokenFile(*authTokens)
+		if err != nil {
+			log.Fatalf("Failed to load auth tokens: %v", err)
+		}
+	}
+
+	var allowedOrigins []string
+	if *wsOrigins != "" {
+		for _, origin := range strings.Split(*wsOrigins, ",") {
+			allowedOrigins = append(allowedOrigins, strings.TrimSpace(origin))
+		}
+	}
 
 	switch *transportType {
 	case "stdio":
-		stdioTransport := transport.NewStdioTransport()
+		stdioTransport := transport.NewStdioTransportWithServer(server)
 		err = stdioTransport.Run()
 	case "http":
-		httpTransport := transport.NewHTTPTransport(*port, false, "", "")
+		httpTransport := transport.NewHTTPTransportWithServer(server, *port, false, "", "")
+		httpTransport.SetAuthenticator(auth)
 		err = httpTransport.Run()
 	case "https":
-		if *certFile == "" || *keyFile == "" {
-			log.Fatal("HTTPS transport requires both -cert and -key flags")
-		}
-		httpTransport := transport.NewHTTPTransport(*port, true, *certFile, *keyFile)
+		httpTransport := transport.NewHTTPTransportWithServer(server, *port, true, *certFile, *keyFile)
+		httpTransport.SetAuthenticator(auth)
 		err = httpTransport.Run()
+	case "ws":
+		wsTransport := transport.NewWebSocketTransportWithServer(server, *port, false, "", "")
+		wsTransport.SetAuthenticator(auth)
+		wsTransport.SetAllowedOrigins(allowedOrigins...)
+		err = wsTransport.Run()
+	case "wss":
+		wsTransport := transport.NewWebSocketTransportWithServer(server, *port, true, *certFile, *keyFile)
+		wsTransport.SetAuthenticator(auth)
+		wsTransport.SetAllowedOrigins(allowedOrigins...)
+		err = wsTransport.Run()
+	case "unix":
+		var unixTransport *transport.UnixTransport
+		unixTransport, err = unixTransportFromFlags(server, *socketPath, *socketMode, *socketProto, *socketUIDs)
+		if err != nil {
+			log.Fatalf("Invalid unix transport: %v", err)
+		}
+		err = unixTransport.Run()
 	default:
-		log.Fatalf("Invalid transport: %s. Use 'stdio', 'http', or 'https'", *transportType)
+		log.Fatalf("Invalid transport: %s. Use 'stdio', 'http', 'https', 'ws', 'wss', or 'unix'", *transportType)
+	}
+
+	if closeErr := backend.Close(); closeErr != nil {
+		log.Printf("Error closing storage: %v", closeErr)
 	}
 
 	if err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// storageDSNFromFlags combines the -storage and -data-dir flags into a DSN.
+// A bare driver name takes its location from -data-dir, and -data-dir alone
+// selects the file driver.
+func storageDSNFromFlags(storageFlag, dataDir string) string {
+	switch {
+	case storageFlag == "" && dataDir == "":
+		return "memory"
+	case storageFlag == "":
+		return "file:" + dataDir
+	case !strings.Contains(storageFlag, ":") && dataDir != "":
+		return storageFlag + ":" + dataDir
+	default:
+		return storageFlag
+	}
+}
+
+// unixTransportFromFlags creates the unix transport from its -socket flags
+func unixTransportFromFlags(server *mcp.Server, path, mode, protocol, uids string) (*transport.UnixTransport, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0o777 {
+		return nil, fmt.Errorf("invalid socket mode %q", mode)
+	}
+	ut, err := transport.NewUnixTransportWithServer(server, path, os.FileMode(perm), protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	if uids != "" {
+		var allowed []uint32
+		for _, field := range strings.Split(uids, ",") {
+			uid, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid user ID %q", field)
+			}
+			allowed = append(allowed, uint32(uid))
+		}
+		ut.AllowUIDs(allowed...)
+	}
+	return ut, nil
+}
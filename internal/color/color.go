@@ -0,0 +1,407 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package color parses CSS color values and canonicalizes them to sRGB.
+//
+// Supported syntaxes are the CSS named colors and transparent, #rgb, #rgba,
+// #rrggbb and #rrggbbaa, and the rgb(), rgba(), hsl(), hsla(), hwb(),
+// lab(), lch(), oklab() and oklch() functions in both the legacy comma
+// syntax and the modern space syntax with an optional "/ alpha". Colors
+// outside the sRGB gamut are clamped into it.
+package color
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is wrapped by every error returned from Parse
+var ErrInvalid = errors.New("invalid color")
+
+// Color is an sRGB color. Channels are gamma-encoded and, like alpha, lie
+// in [0, 1].
+type Color struct {
+	R, G, B, A float64
+}
+
+// Parse parses a CSS color value. Matching is case-insensitive and ignores
+// surrounding whitespace.
+func Parse(s string) (Color, error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	if value == "" {
+		return Color{}, fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+
+	var (
+		c   Color
+		err error
+	)
+	switch {
+	case strings.HasPrefix(value, "#"):
+		c, err = parseHex(value[1:])
+	case strings.HasSuffix(value, ")"):
+		c, err = parseFunction(value)
+	case value == "transparent":
+		c = Color{}
+	default:
+		rgb, ok := namedColors[value]
+		if !ok {
+			err = errors.New("unknown color name")
+		}
+		c = fromRGB24(rgb)
+	}
+
+	if err != nil {
+		return Color{}, fmt.Errorf("%w %q: %v", ErrInvalid, s, err)
+	}
+	return c.clamped(), nil
+}
+
+// Hex returns the canonical form of the color: #rrggbb for opaque colors
+// and #rrggbbaa otherwise
+func (c Color) Hex() string {
+	r, g, b, a := c.RGBA8()
+	if a == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, a)
+}
+
+// RGBA8 returns the channels rounded to 8 bits
+func (c Color) RGBA8() (r, g, b, a uint8) {
+	return to8(c.R), to8(c.G), to8(c.B), to8(c.A)
+}
+
+func to8(v float64) uint8 {
+	return uint8(math.Round(clamp01(v) * 255))
+}
+
+func fromRGB24(rgb uint32) Color {
+	return Color{
+		R: float64(rgb>>16&0xff) / 255,
+		G: float64(rgb>>8&0xff) / 255,
+		B: float64(rgb&0xff) / 255,
+		A: 1,
+	}
+}
+
+func (c Color) clamped() Color {
+	return Color{R: clamp01(c.R), G: clamp01(c.G), B: clamp01(c.B), A: clamp01(c.A)}
+}
+
+func clamp01(v float64) float64 {
+	if math.IsNaN(v) || v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// parseHex parses the digits of a #rgb, #rgba, #rrggbb or #rrggbbaa color
+func parseHex(digits string) (Color, error) {
+	switch len(digits) {
+	case 3, 4:
+		// Expand each digit: #abc is #aabbcc.
+		expanded := make([]byte, 0, 2*len(digits))
+		for i := 0; i < len(digits); i++ {
+			expanded = append(expanded, digits[i], digits[i])
+		}
+		digits = string(expanded)
+	case 6, 8:
+	default:
+		return Color{}, errors.New("hex colors need 3, 4, 6 or 8 digits")
+	}
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return Color{}, errors.New("bad hex digit")
+	}
+
+	if len(digits) == 6 {
+		return fromRGB24(uint32(value)), nil
+	}
+	c := fromRGB24(uint32(value >> 8))
+	c.A = float64(value&0xff) / 255
+	return c, nil
+}
+
+// component is one argument of a color function
+type component struct {
+	value   float64
+	percent bool
+	angle   bool // carried an angle unit; value is in degrees
+}
+
+// number resolves a component that is a plain number or a percentage of
+// percentScale
+func (c component) number(percentScale float64) float64 {
+	if c.percent {
+		return c.value / 100 * percentScale
+	}
+	return c.value
+}
+
+// parseFunction parses name(args)
+func parseFunction(value string) (Color, error) {
+	open := strings.IndexByte(value, '(')
+	if open < 0 {
+		return Color{}, errors.New("missing '('")
+	}
+	name := strings.TrimSpace(value[:open])
+	channels, alpha, err := parseArguments(value[open+1 : len(value)-1])
+	if err != nil {
+		return Color{}, err
+	}
+
+	var c Color
+	switch name {
+	case "rgb", "rgba":
+		c = Color{
+			R: channels[0].number(255) / 255,
+			G: channels[1].number(255) / 255,
+			B: channels[2].number(255) / 255,
+		}
+	case "hsl", "hsla":
+		c = hslToRGB(hue(channels[0]), channels[1].number(100)/100, channels[2].number(100)/100)
+	case "hwb":
+		c = hwbToRGB(hue(channels[0]), channels[1].number(100)/100, channels[2].number(100)/100)
+	case "lab":
+		c = labToRGB(channels[0].number(100), channels[1].number(125), channels[2].number(125))
+	case "lch":
+		a, b := polarToCartesian(channels[1].number(150), hue(channels[2]))
+		c = labToRGB(channels[0].number(100), a, b)
+	case "oklab":
+		c = oklabToRGB(channels[0].number(1), channels[1].number(0.4), channels[2].number(0.4))
+	case "oklch":
+		a, b := polarToCartesian(channels[1].number(0.4), hue(channels[2]))
+		c = oklabToRGB(channels[0].number(1), a, b)
+	default:
+		return Color{}, fmt.Errorf("unknown color function %q", name)
+	}
+
+	c.A = 1
+	if alpha != nil {
+		c.A = alpha.number(1)
+	}
+	return c, nil
+}
+
+// parseArguments splits the arguments of a color function into three
+// channels and an optional alpha. Both "a, b, c[, alpha]" and
+// "a b c[ / alpha]" are accepted.
+func parseArguments(args string) ([3]component, *component, error) {
+	var channels [3]component
+	var tokens []string
+	var alphaToken string
+
+	if strings.Contains(args, ",") {
+		tokens = strings.Split(args, ",")
+		for i := range tokens {
+			tokens[i] = strings.TrimSpace(tokens[i])
+		}
+		if len(tokens) == 4 {
+			alphaToken = tokens[3]
+			tokens = tokens[:3]
+		}
+	} else {
+		main, alpha, hasAlpha := strings.Cut(args, "/")
+		tokens = strings.Fields(main)
+		if hasAlpha {
+			alphaToken = strings.TrimSpace(alpha)
+			if alphaToken == "" {
+				return channels, nil, errors.New("missing alpha after '/'")
+			}
+		}
+	}
+
+	if len(tokens) != 3 {
+		return channels, nil, fmt.Errorf("expected 3 channels, got %d", len(tokens))
+	}
+	for i, token := range tokens {
+		c, err := parseComponent(token)
+		if err != nil {
+			return channels, nil, err
+		}
+		channels[i] = c
+	}
+
+	if alphaToken == "" {
+		return channels, nil, nil
+	}
+	alpha, err := parseComponent(alphaToken)
+	if err != nil {
+		return channels, nil, err
+	}
+	if alpha.angle {
+		return channels, nil, errors.New("alpha cannot be an angle")
+	}
+	return channels, &alpha, nil
+}
+
+// angleUnits converts CSS angle units to degrees. grad is listed before rad
+// because it ends with it.
+var angleUnits = []struct {
+	suffix string
+	scale  float64
+}{
+	{"deg", 1},
+	{"grad", 0.9},
+	{"rad", 180 / math.Pi},
+	{"turn", 360},
+}
+
+// parseComponent parses a number, percentage, angle or the none keyword
+func parseComponent(token string) (component, error) {
+	// A missing component behaves as zero when converting to sRGB
+	if token == "none" {
+		return component{}, nil
+	}
+
+	if strings.HasSuffix(token, "%") {
+		v, err := strconv.ParseFloat(token[:len(token)-1], 64)
+		if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+			return component{}, fmt.Errorf("bad percentage %q", token)
+		}
+		return component{value: v, percent: true}, nil
+	}
+
+	for _, unit := range angleUnits {
+		if strings.HasSuffix(token, unit.suffix) {
+			v, err := strconv.ParseFloat(token[:len(token)-len(unit.suffix)], 64)
+			if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+				return component{}, fmt.Errorf("bad angle %q", token)
+			}
+			return component{value: v * unit.scale, angle: true}, nil
+		}
+	}
+
+	v, err := strconv.ParseFloat(token, 64)
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return component{}, fmt.Errorf("bad number %q", token)
+	}
+	return component{value: v}, nil
+}
+
+// hue returns a hue component in degrees, normalized to [0, 360)
+func hue(c component) float64 {
+	h := math.Mod(c.value, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func hslToRGB(h, s, l float64) Color {
+	s, l = clamp01(s), clamp01(l)
+	f := func(n float64) float64 {
+		k := math.Mod(n+h/30, 12)
+		a := s * math.Min(l, 1-l)
+		return l - a*math.Max(-1, math.Min(math.Min(k-3, 9-k), 1))
+	}
+	return Color{R: f(0), G: f(8), B: f(4)}
+}
+
+func hwbToRGB(h, white, black float64) Color {
+	white, black = clamp01(white), clamp01(black)
+	if white+black >= 1 {
+		gray := white / (white + black)
+		return Color{R: gray, G: gray, B: gray}
+	}
+	c := hslToRGB(h, 1, 0.5)
+	scale := 1 - white - black
+	return Color{R: c.R*scale + white, G: c.G*scale + white, B: c.B*scale + white}
+}
+
+func polarToCartesian(chroma, hueDegrees float64) (float64, float64) {
+	if chroma < 0 {
+		chroma = 0
+	}
+	rad := hueDegrees * math.Pi / 180
+	return chroma * math.Cos(rad), chroma * math.Sin(rad)
+}
+
+// labToRGB converts CIE Lab (D50) to sRGB following CSS Color 4
+func labToRGB(l, a, b float64) Color {
+	const (
+		epsilon = 216.0 / 24389
+		kappa   = 24389.0 / 27
+	)
+
+	fy := (l + 16) / 116
+	fx := fy + a/500
+	fz := fy - b/200
+
+	x := fx * fx * fx
+	if x <= epsilon {
+		x = (116*fx - 16) / kappa
+	}
+	y := fy * fy * fy
+	if l <= kappa*epsilon {
+		y = l / kappa
+	}
+	z := fz * fz * fz
+	if z <= epsilon {
+		z = (116*fz - 16) / kappa
+	}
+
+	// Scale by the D50 white point
+	x *= 0.3457 / 0.3585
+	z *= (1 - 0.3457 - 0.3585) / 0.3585
+
+	// Bradford chromatic adaptation from D50 to D65
+	x65 := 0.955473421488075*x - 0.02309845494876471*y + 0.06325924320057072*z
+	y65 := -0.0283697093338637*x + 1.0099953980813041*y + 0.021041441191917323*z
+	z65 := 0.012314014864481998*x - 0.020507649298898964*y + 1.330365926242124*z
+
+	return Color{
+		R: gammaEncode(3.2409699419045226*x65 - 1.537383177570094*y65 - 0.4986107602930034*z65),
+		G: gammaEncode(-0.9692436362808796*x65 + 1.8759675015077202*y65 + 0.04155505740717559*z65),
+		B: gammaEncode(0.05563007969699366*x65 - 0.20397695888897652*y65 + 1.0569715142428786*z65),
+	}
+}
+
+// oklabToRGB converts OKLab to sRGB
+func oklabToRGB(l, a, b float64) Color {
+	lms := [3]float64{
+		l + 0.3963377773761749*a + 0.2158037573099136*b,
+		l - 0.1055613458156586*a - 0.0638541728258133*b,
+		l - 0.0894841775298119*a - 1.2914855480194092*b,
+	}
+	for i, v := range lms {
+		lms[i] = v * v * v
+	}
+
+	return Color{
+		R: gammaEncode(4.0767416621*lms[0] - 3.3077115913*lms[1] + 0.2309699292*lms[2]),
+		G: gammaEncode(-1.2684380046*lms[0] + 2.6097574011*lms[1] - 0.3413193965*lms[2]),
+		B: gammaEncode(-0.0041960863*lms[0] - 0.7034186147*lms[1] + 1.7076147010*lms[2]),
+	}
+}
+
+// gammaEncode applies the sRGB transfer function to a linear channel
+func gammaEncode(v float64) float64 {
+	sign := 1.0
+	if v < 0 {
+		sign, v = -1, -v
+	}
+	if v <= 0.0031308 {
+		return sign * 12.92 * v
+	}
+	return sign * (1.055*math.Pow(v, 1/2.4) - 0.055)
+}
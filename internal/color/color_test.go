@@ -0,0 +1,138 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package color
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_Canonical(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Named colors
+		{"blue", "#0000ff"},
+		{"  Blue ", "#0000ff"},
+		{"REBECCAPURPLE", "#663399"},
+		{"transparent", "#00000000"},
+
+		// Hex
+		{"#00f", "#0000ff"},
+		{"#0000FF", "#0000ff"},
+		{"#00f8", "#0000ff88"},
+		{"#0000ff80", "#0000ff80"},
+		{"#0000ffff", "#0000ff"},
+
+		// rgb() / rgba()
+		{"rgb(0,0,255)", "#0000ff"},
+		{"rgb(0, 0, 255)", "#0000ff"},
+		{"rgba(0, 0, 255, 0.5)", "#0000ff80"},
+		{"rgb(0 0 255 / 50%)", "#0000ff80"},
+		{"rgb(0% 0% 100%)", "#0000ff"},
+		{"rgb(300 -20 255)", "#ff00ff"},
+		{"rgb(none 0 255)", "#0000ff"},
+
+		// hsl() / hsla()
+		{"hsl(240, 100%, 50%)", "#0000ff"},
+		{"hsl(240deg 100% 50%)", "#0000ff"},
+		{"hsla(120, 100%, 25%, 1)", "#008000"},
+		{"hsl(0.5turn 100% 50%)", "#00ffff"},
+		{"hsl(-120 100% 50%)", "#0000ff"},
+		{"hsl(200grad 100% 50%)", "#00ffff"},
+		{"hsl(3.14159rad 100% 50%)", "#00ffff"},
+
+		// hwb()
+		{"hwb(240 0% 0%)", "#0000ff"},
+		{"hwb(0 100% 100%)", "#808080"},
+		{"hwb(120 0% 50% / 0.5)", "#00800080"},
+
+		// lab() / lch() / oklab() / oklch(), using the CSS Color 4 examples
+		// that all denote rgb(125, 35, 41)
+		{"lab(29.2345% 39.3825 20.0664)", "#7d2329"},
+		{"lch(29.2345% 44.2 27)", "#7d2329"},
+		{"oklab(40.101% 0.1147 0.0453)", "#7d2329"},
+		{"oklch(40.101% 0.12332 21.555)", "#7d2329"},
+		{"lab(100 0 0)", "#ffffff"},
+		{"oklch(0 0 0)", "#000000"},
+
+		// Out of gamut colors are clamped
+		{"oklch(70% 0.4 145)", "#00d200"},
+	}
+
+	for _, tt := range tests {
+		c, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := c.Hex(); got != tt.want {
+			t.Errorf("Parse(%q).Hex() = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"bluish",
+		"#12",
+		"#12345",
+		"#ggg",
+		"rgb(0, 0)",
+		"rgb(0 0 0 0)",
+		"rgb(0 0 0 /)",
+		"rgb(a b c)",
+		"cmyk(0 0 0 0)",
+		"hsl(0 0% 0% / 90deg)",
+		"color-1",
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(input); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalid", input, err)
+		}
+	}
+}
+
+func TestColor_RGBA8(t *testing.T) {
+	c, err := Parse("rgba(255, 128, 0, 0.25)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	r, g, b, a := c.RGBA8()
+	if r != 255 || g != 128 || b != 0 || a != 64 {
+		t.Errorf("Expected 255,128,0,64, got %d,%d,%d,%d", r, g, b, a)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = Parse("oklch(40.101% 0.12332 21.555 / 50%)")
+	}
+}
+
+func TestNamedColors(t *testing.T) {
+	names := NamedColors()
+	if len(names) != len(namedColors) || names[0] != "aliceblue" || names[len(names)-1] != "yellowgreen" {
+		t.Errorf("Expected every named color in order, got %d from %q", len(names), names[0])
+	}
+	for _, name := range names {
+		if _, err := Parse(name); err != nil {
+			t.Errorf("Parse(%q): %v", name, err)
+		}
+	}
+}
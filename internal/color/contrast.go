@@ -0,0 +1,43 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package color
+
+import "math"
+
+// Luminance returns the relative luminance of the color as defined by WCAG
+// 2, from 0 for black to 1 for white. Alpha is ignored.
+func (c Color) Luminance() float64 {
+	return 0.2126*gammaDecode(c.R) + 0.7152*gammaDecode(c.G) + 0.0722*gammaDecode(c.B)
+}
+
+// ContrastRatio returns the WCAG 2 contrast ratio of two colors, from 1 to
+// 21. Text needs 4.5 against its background to meet level AA, or 3 when it
+// is large.
+func ContrastRatio(a, b Color) float64 {
+	la, lb := a.Luminance(), b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// gammaDecode converts a gamma-encoded sRGB channel to linear light, undoing
+// gammaEncode
+func gammaDecode(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
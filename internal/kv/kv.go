@@ -0,0 +1,445 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kv implements a small embedded key-value store.
+//
+// The database is a single append-only log of checksummed batches. The whole
+// key space is indexed in memory, so it is meant for modest data sets such as
+// a favorites list. Each batch is fsynced before Write returns and is applied
+// atomically: a batch torn by a crash is discarded when the log is reopened.
+// The log is rewritten once most of it is made up of overwritten entries.
+package kv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Record layout: crc32 (4 bytes) | payload length (4 bytes) | payload.
+// The payload is a sequence of ops: kind (1 byte) | key length (uvarint) |
+// value length (uvarint) | key | value.
+const (
+	headerSize = 8
+	opPut      = 1
+	opDelete   = 2
+
+	// compactMinDead is the number of dead entries below which the log is
+	// never rewritten, regardless of the live/dead ratio.
+	compactMinDead = 1000
+)
+
+// ErrClosed is returned by operations on a closed database
+var ErrClosed = errors.New("kv: database is closed")
+
+// ErrCorrupt is returned by Open when the log is damaged somewhere other
+// than its final record
+var ErrCorrupt = errors.New("kv: log corrupted")
+
+// errChecksum is returned by readRecord for a record whose payload does not
+// match its checksum
+var errChecksum = errors.New("checksum mismatch")
+
+// DB is an embedded key-value database backed by a single file
+type DB struct {
+	mutex sync.RWMutex
+	path  string
+	file  *os.File
+	index map[string][]byte
+	dead  int   // overwritten or deleted entries still present in the log
+	size  int64 // length of the log up to its last complete record
+	err   error // set when a torn record could not be rolled back
+}
+
+// Batch collects puts and deletes that are written atomically
+type Batch struct {
+	ops []op
+}
+
+type op struct {
+	kind  byte
+	key   string
+	value []byte
+}
+
+// Put adds a put of key to the batch
+func (b *Batch) Put(key string, value []byte) {
+	b.ops = append(b.ops, op{kind: opPut, key: key, value: value})
+}
+
+// Delete adds a deletion of key to the batch
+func (b *Batch) Delete(key string) {
+	b.ops = append(b.ops, op{kind: opDelete, key: key})
+}
+
+// Len returns the number of operations in the batch
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Open opens the database at path, creating it if needed. A torn final
+// record left by a crash is truncated away.
+func Open(path string) (*DB, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) // #nosec G304 -- path is chosen by the operator
+	if err != nil {
+		return nil, fmt.Errorf("kv: error opening %s: %w", path, err)
+	}
+
+	db := &DB{
+		path:  path,
+		file:  file,
+		index: make(map[string][]byte),
+	}
+
+	valid, err := db.load()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if err := file.Truncate(valid); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("kv: error truncating torn record: %w", err)
+	}
+	if _, err := file.Seek(valid, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("kv: error seeking log: %w", err)
+	}
+	db.size = valid
+
+	return db, nil
+}
+
+// load replays the log into the index and returns the length of its valid
+// prefix.
+func (db *DB) load() (int64, error) {
+	info, err := db.file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("kv: error reading log: %w", err)
+	}
+
+	reader := bufio.NewReader(db.file)
+	var offset int64
+
+	for {
+		payload, err := readRecord(reader, info.Size()-offset)
+		if errors.Is(err, io.EOF) {
+			return offset, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// Torn final record: the process died mid-write.
+			return offset, nil
+		}
+		if err != nil {
+			// A bad checksum on the last record is a torn write too; anywhere
+			// else it means the log is damaged.
+			if _, peekErr := reader.Peek(1); errors.Is(err, errChecksum) && errors.Is(peekErr, io.EOF) {
+				return offset, nil
+			}
+			return 0, fmt.Errorf("%w at offset %d: %v", ErrCorrupt, offset, err)
+		}
+
+		ops, err := decodeOps(payload)
+		if err != nil {
+			return 0, fmt.Errorf("%w at offset %d: %v", ErrCorrupt, offset, err)
+		}
+		db.apply(ops)
+		offset += int64(headerSize + len(payload))
+	}
+}
+
+// readRecord reads one checksummed record and returns its payload. remaining
+// is the number of bytes left in the log, which bounds the payload length.
+func readRecord(reader *bufio.Reader, remaining int64) ([]byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[4:])
+	if int64(length) > remaining-headerSize {
+		// Only the last record can be cut short by a crash. A valid record
+		// further on means the length itself is damaged.
+		rest, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		if containsRecord(rest) {
+			return nil, errors.New("record length past the end of the log")
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[:4]) {
+		return nil, errChecksum
+	}
+	return payload, nil
+}
+
+// containsRecord reports whether a complete, checksummed record starts
+// anywhere in data
+func containsRecord(data []byte) bool {
+	for i := 0; i+headerSize <= len(data); i++ {
+		length := int64(binary.LittleEndian.Uint32(data[i+4:]))
+		// Batches are never empty, and an all-zero header checks out
+		if length == 0 || length > int64(len(data)-i-headerSize) {
+			continue
+		}
+		payload := data[i+headerSize : int64(i+headerSize)+length]
+		if crc32.ChecksumIEEE(payload) == binary.LittleEndian.Uint32(data[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeOps decodes a record payload
+func decodeOps(payload []byte) ([]op, error) {
+	var ops []op
+	for len(payload) > 0 {
+		kind := payload[0]
+		if kind != opPut && kind != opDelete {
+			return nil, fmt.Errorf("unknown op %d", kind)
+		}
+		payload = payload[1:]
+
+		keyLen, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return nil, errors.New("bad key length")
+		}
+		payload = payload[n:]
+
+		valueLen, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return nil, errors.New("bad value length")
+		}
+		payload = payload[n:]
+
+		if uint64(len(payload)) < keyLen+valueLen {
+			return nil, errors.New("truncated op")
+		}
+		o := op{kind: kind, key: string(payload[:keyLen])}
+		if kind == opPut {
+			o.value = append([]byte(nil), payload[keyLen:keyLen+valueLen]...)
+		}
+		payload = payload[keyLen+valueLen:]
+		ops = append(ops, o)
+	}
+	return ops, nil
+}
+
+// encodeRecord encodes ops as a checksummed record
+func encodeRecord(ops []op) []byte {
+	record := make([]byte, headerSize, headerSize+64*len(ops))
+	var lenBuf [binary.MaxVarintLen64]byte
+
+	for _, o := range ops {
+		record = append(record, o.kind)
+		n := binary.PutUvarint(lenBuf[:], uint64(len(o.key)))
+		record = append(record, lenBuf[:n]...)
+		n = binary.PutUvarint(lenBuf[:], uint64(len(o.value)))
+		record = append(record, lenBuf[:n]...)
+		record = append(record, o.key...)
+		record = append(record, o.value...)
+	}
+
+	payload := record[headerSize:]
+	binary.LittleEndian.PutUint32(record[:4], crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(record[4:headerSize], uint32(len(payload))) // #nosec G115 -- batches are far below 4GiB
+	return record
+}
+
+// apply updates the index. The caller must hold the write lock.
+func (db *DB) apply(ops []op) {
+	for _, o := range ops {
+		if _, exists := db.index[o.key]; exists {
+			db.dead++
+		}
+		switch o.kind {
+		case opPut:
+			db.index[o.key] = o.value
+		case opDelete:
+			delete(db.index, o.key)
+			db.dead++ // the tombstone itself
+		}
+	}
+}
+
+// Get returns the value stored under key
+func (db *DB) Get(key string) ([]byte, bool) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	value, ok := db.index[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), value...), true
+}
+
+// Keys returns the keys starting with prefix in sorted order
+func (db *DB) Keys(prefix string) []string {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	keys := make([]string, 0)
+	for key := range db.index {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Put durably stores value under key
+func (db *DB) Put(key string, value []byte) error {
+	var batch Batch
+	batch.Put(key, value)
+	return db.Write(&batch)
+}
+
+// Delete durably removes key
+func (db *DB) Delete(key string) error {
+	var batch Batch
+	batch.Delete(key)
+	return db.Write(&batch)
+}
+
+// Write durably and atomically applies a batch
+func (db *DB) Write(batch *Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.file == nil {
+		return ErrClosed
+	}
+	if db.err != nil {
+		return db.err
+	}
+
+	record := encodeRecord(batch.ops)
+	if _, err := db.file.Write(record); err != nil {
+		return db.rollback(fmt.Errorf("kv: error writing log: %w", err))
+	}
+	if err := db.file.Sync(); err != nil {
+		return db.rollback(fmt.Errorf("kv: error syncing log: %w", err))
+	}
+	db.size += int64(len(record))
+	db.apply(batch.ops)
+
+	if db.dead >= compactMinDead && db.dead > len(db.index) {
+		// The batch is already durable; a failed rewrite only wastes space
+		// and is retried on the next write.
+		_ = db.compact()
+	}
+	return nil
+}
+
+// rollback truncates the log back to its last complete record after a
+// failed write, so that later records do not follow torn bytes. If that
+// fails too, the database refuses further writes until it is reopened. The
+// caller must hold the write lock.
+func (db *DB) rollback(err error) error {
+	if truncErr := db.file.Truncate(db.size); truncErr != nil {
+		db.err = fmt.Errorf("kv: log unusable after failed write: %w", truncErr)
+		return err
+	}
+	if _, seekErr := db.file.Seek(db.size, io.SeekStart); seekErr != nil {
+		db.err = fmt.Errorf("kv: log unusable after failed write: %w", seekErr)
+	}
+	return err
+}
+
+// compact rewrites the log with only the live entries. The caller must hold
+// the write lock.
+func (db *DB) compact() error {
+	keys := make([]string, 0, len(db.index))
+	for key := range db.index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ops := make([]op, 0, len(keys))
+	for _, key := range keys {
+		ops = append(ops, op{kind: opPut, key: key, value: db.index[key]})
+	}
+
+	// The temporary file becomes the live log once renamed into place, so
+	// it is opened for both writing and later appends.
+	tmpPath := db.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600) // #nosec G304 -- derived from the database path
+	if err != nil {
+		return err
+	}
+	var size int64
+	if len(ops) > 0 {
+		record := encodeRecord(ops)
+		if _, err := tmp.Write(record); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		size = int64(len(record))
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, db.path); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if d, err := os.Open(filepath.Dir(db.path)); err == nil { // #nosec G304 -- directory of the database path
+		_ = d.Sync()
+		_ = d.Close()
+	}
+
+	_ = db.file.Close()
+	db.file = tmp
+	db.size = size
+	db.dead = 0
+	return nil
+}
+
+// Close closes the database
+func (db *DB) Close() error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.file == nil {
+		return ErrClosed
+	}
+	err := db.file.Close()
+	db.file = nil
+	return err
+}
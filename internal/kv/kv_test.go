@@ -0,0 +1,251 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestDB(t *testing.T, path string) *DB {
+	t.Helper()
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+func TestDB_PutGetDelete(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.kv"))
+	defer db.Close()
+
+	if err := db.Put("a", []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	value, ok := db.Get("a")
+	if !ok || string(value) != "1" {
+		t.Errorf("Expected a=1, got %q (found=%v)", value, ok)
+	}
+
+	if err := db.Delete("a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := db.Get("a"); ok {
+		t.Error("Expected a to be deleted")
+	}
+}
+
+func TestDB_KeysSortedByPrefix(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.kv"))
+	defer db.Close()
+
+	for _, key := range []string{"b/2", "a/1", "b/1", "c"} {
+		if err := db.Put(key, nil); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	if keys := db.Keys("b/"); strings.Join(keys, ",") != "b/1,b/2" {
+		t.Errorf("Expected [b/1 b/2], got %v", keys)
+	}
+	if keys := db.Keys(""); len(keys) != 4 {
+		t.Errorf("Expected 4 keys, got %v", keys)
+	}
+}
+
+func TestDB_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.kv")
+
+	db := openTestDB(t, path)
+	var batch Batch
+	batch.Put("x", []byte("1"))
+	batch.Put("y", []byte("2"))
+	batch.Delete("x")
+	if err := db.Write(&batch); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	db.Close()
+
+	db = openTestDB(t, path)
+	defer db.Close()
+
+	if _, ok := db.Get("x"); ok {
+		t.Error("Expected x to stay deleted after reopen")
+	}
+	if value, _ := db.Get("y"); string(value) != "2" {
+		t.Errorf("Expected y=2 after reopen, got %q", value)
+	}
+}
+
+func TestDB_TornBatchIsDiscarded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.kv")
+
+	db := openTestDB(t, path)
+	if err := db.Put("kept", []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	db.Close()
+
+	// Append a batch cut off halfway through, as a crash mid-write would.
+	record := encodeRecord([]op{
+		{kind: opPut, key: "lost-1", value: []byte("x")},
+		{kind: opPut, key: "lost-2", value: []byte("y")},
+	})
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	if _, err := f.Write(record[:len(record)-3]); err != nil {
+		t.Fatalf("Failed to write torn record: %v", err)
+	}
+	f.Close()
+
+	db = openTestDB(t, path)
+	if _, ok := db.Get("lost-1"); ok {
+		t.Error("Expected torn batch to be discarded entirely")
+	}
+	if err := db.Put("after", []byte("1")); err != nil {
+		t.Fatalf("Put after recovery failed: %v", err)
+	}
+	db.Close()
+
+	db = openTestDB(t, path)
+	defer db.Close()
+	if keys := db.Keys(""); strings.Join(keys, ",") != "after,kept" {
+		t.Errorf("Expected [after kept], got %v", keys)
+	}
+}
+
+func TestDB_CorruptionInMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.kv")
+
+	db := openTestDB(t, path)
+	db.Put("a", []byte("1"))
+	db.Put("b", []byte("2"))
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	data[headerSize+2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	if _, err := Open(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestDB_CorruptLengthInMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.kv")
+
+	db := openTestDB(t, path)
+	db.Put("a", []byte("1"))
+	db.Put("b", []byte("2"))
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	// A length past the end of the log, with a record still following
+	data[7] = 0x7f
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	if _, err := Open(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestDB_FailedWriteIsRolledBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.kv")
+
+	db := openTestDB(t, path)
+	if err := db.Put("kept", []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// Part of a record reached the file before the write failed
+	record := encodeRecord([]op{{kind: opPut, key: "lost", value: []byte("x")}})
+	if _, err := db.file.Write(record[:len(record)-3]); err != nil {
+		t.Fatalf("Failed to write torn record: %v", err)
+	}
+	failure := errors.New("disk full")
+	if err := db.rollback(failure); err != failure {
+		t.Fatalf("Expected the write error, got %v", err)
+	}
+
+	if err := db.Put("after", []byte("1")); err != nil {
+		t.Fatalf("Put after rollback failed: %v", err)
+	}
+	db.Close()
+
+	db = openTestDB(t, path)
+	defer db.Close()
+	if keys := db.Keys(""); strings.Join(keys, ",") != "after,kept" {
+		t.Errorf("Expected [after kept], got %v", keys)
+	}
+}
+
+func TestDB_Compaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.kv")
+
+	db := openTestDB(t, path)
+	for i := 0; i < 3*compactMinDead; i++ {
+		if err := db.Put(fmt.Sprintf("key-%d", i%10), []byte(fmt.Sprint(i))); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat log: %v", err)
+	}
+	if info.Size() > 64*int64(compactMinDead) {
+		t.Errorf("Expected log to be compacted, size is %d bytes", info.Size())
+	}
+
+	if err := db.Put("key-0", []byte("last")); err != nil {
+		t.Fatalf("Put after compaction failed: %v", err)
+	}
+	db.Close()
+
+	db = openTestDB(t, path)
+	defer db.Close()
+	if len(db.Keys("")) != 10 {
+		t.Errorf("Expected 10 keys after compaction, got %d", len(db.Keys("")))
+	}
+	if value, _ := db.Get("key-0"); string(value) != "last" {
+		t.Errorf("Expected key-0=last, got %q", value)
+	}
+}
+
+func TestDB_Closed(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.kv"))
+	db.Close()
+
+	if err := db.Put("a", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Message is a JSON-RPC message as received by a transport: a single
+// request, or a batch of them
+type Message struct {
+	// Batch is set if the message was a JSON array
+	Batch bool
+	// Requests holds the requests of the message in order
+	Requests []JSONRPCRequest
+
+	// invalid holds the error answering each request that is not a valid
+	// JSON-RPC request, by position
+	invalid map[int]*JSONRPCError
+}
+
+// ParseMessage decodes a JSON-RPC message. The error, returned for data that
+// is not JSON or is an empty batch, is to be sent to the client as the
+// whole reply. Invalid requests within a batch are answered individually
+// when the message is handled.
+func ParseMessage(data []byte) (*Message, *JSONRPCError) {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		return nil, &JSONRPCError{
+			Code:    -32700,
+			Message: "Parse error",
+		}
+	}
+
+	if data[0] != '[' {
+		msg := &Message{}
+		msg.add(data)
+		return msg, nil
+	}
+
+	var members []json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, &JSONRPCError{
+			Code:    -32700,
+			Message: "Parse error",
+			Data:    err.Error(),
+		}
+	}
+	if len(members) == 0 {
+		return nil, &JSONRPCError{
+			Code:    -32600,
+			Message: "Invalid Request",
+			Data:    "empty batch",
+		}
+	}
+
+	msg := &Message{Batch: true}
+	for _, member := range members {
+		msg.add(member)
+	}
+	return msg, nil
+}
+
+// add decodes a request and appends it to the message
+func (m *Message) add(data []byte) {
+	var req JSONRPCRequest
+	err := json.Unmarshal(data, &req)
+	switch {
+	case err != nil:
+		// There is no id to echo
+		m.reject(JSONRPCRequest{}, "not a request object")
+	case req.JSONRPC != "2.0":
+		m.reject(req, `jsonrpc must be "2.0"`)
+	case req.Method == "":
+		m.reject(req, "method is required")
+	case m.Batch && req.Method == "initialize":
+		m.reject(req, "initialize cannot be batched")
+	default:
+		m.Requests = append(m.Requests, req)
+	}
+}
+
+func (m *Message) reject(req JSONRPCRequest, reason string) {
+	if m.invalid == nil {
+		m.invalid = make(map[int]*JSONRPCError)
+	}
+	m.invalid[len(m.Requests)] = &JSONRPCError{
+		Code:    -32600,
+		Message: "Invalid Request",
+		Data:    reason,
+	}
+	m.Requests = append(m.Requests, req)
+}
+
+// Method returns the method of a message that is a single valid request, or
+// "" for batches and invalid requests
+func (m *Message) Method() string {
+	if m.Batch || m.invalid != nil {
+		return ""
+	}
+	return m.Requests[0].Method
+}
+
+// NeedsReply reports whether any request of the message will be answered,
+// that is whether it is not made of valid notifications only
+func (m *Message) NeedsReply() bool {
+	if m.invalid != nil {
+		return true
+	}
+	for _, req := range m.Requests {
+		if !req.IsNotification() {
+			return true
+		}
+	}
+	return false
+}
+
+// Reply returns what to send back for a message given the responses of
+// HandleSessionMessage: a single response, an array of them for a batch, or
+// nil if nothing is to be sent
+func (m *Message) Reply(responses []*JSONRPCResponse) interface{} {
+	switch {
+	case len(responses) == 0:
+		return nil
+	case m.Batch:
+		return responses
+	default:
+		return responses[0]
+	}
+}
+
+// Refuse returns what to send back for a message that is not handled, as
+// when a transport has too many requests in flight: each of its requests
+// is answered with err, or with why it is invalid. Notifications it carries
+// are dropped.
+func (m *Message) Refuse(err *JSONRPCError) interface{} {
+	var responses []*JSONRPCResponse
+	for i, req := range m.Requests {
+		if invalid, ok := m.invalid[i]; ok {
+			responses = append(responses, &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: invalid})
+		} else if !req.IsNotification() {
+			responses = append(responses, &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: err})
+		}
+	}
+	return m.Reply(responses)
+}
+
+// HandleSessionMessage processes the requests of a message on behalf of a
+// session, the members of a batch concurrently. It returns the responses in
+// request order, leaving out notifications and cancelled requests.
+func (s *Server) HandleSessionMessage(ctx context.Context, sess *Session, msg *Message) []*JSONRPCResponse {
+	return s.StartSessionMessage(ctx, sess, msg)()
+}
+
+// StartSessionMessage registers the requests of a message as in flight on
+// the session and returns the function that handles them as
+// HandleSessionMessage does, which must be called. Transports that handle
+// messages in the background start them as they are read, so that a
+// notifications/cancelled read next finds the requests it names even if
+// their handling has not begun.
+func (s *Server) StartSessionMessage(ctx context.Context, sess *Session, msg *Message) func() []*JSONRPCResponse {
+	handlers := make([]func() *JSONRPCResponse, len(msg.Requests))
+	for i, req := range msg.Requests {
+		if _, ok := msg.invalid[i]; !ok {
+			handlers[i] = s.startSessionRequest(ctx, sess, req)
+		}
+	}
+
+	return func() []*JSONRPCResponse {
+		responses := make([]*JSONRPCResponse, len(msg.Requests))
+
+		var wg sync.WaitGroup
+		for i, req := range msg.Requests {
+			// Invalid requests are answered even without an id
+			if err, ok := msg.invalid[i]; ok {
+				responses[i] = &JSONRPCResponse{
+					JSONRPC: "2.0",
+					ID:      req.ID,
+					Error:   err,
+				}
+				continue
+			}
+
+			wg.Add(1)
+			go func(i int, handle func() *JSONRPCResponse) {
+				defer wg.Done()
+				responses[i] = handle()
+			}(i, handlers[i])
+		}
+		wg.Wait()
+
+		answered := responses[:0]
+		for _, response := range responses {
+			if response != nil {
+				answered = append(answered, response)
+			}
+		}
+		return answered
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	invalid := func(id, reason string) string {
+		return `{"jsonrpc":"2.0","id":` + id + `,"error":{"code":-32600,"message":"Invalid Request","data":"` + reason + `"}}`
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"single", `{"jsonrpc":"2.0","id":1,"method":"ping"}`, `{"jsonrpc":"2.0","id":1,"result":{}}`},
+		{"notification", `{"jsonrpc":"2.0","method":"ping"}`, ``},
+		{"invalid JSON", `{"jsonrpc":"2.0","method":"ping","params":[`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`},
+		{"invalid JSON batch", `[{"jsonrpc":"2.0","method":"ping"},{"jsonrpc":"2.0","method"]`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`},
+		{"empty", ``, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`},
+		{"empty batch", `[]`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"empty batch"}}`},
+		{"not an object", `1`, invalid("null", "not a request object")},
+		{"missing version", `{"id":1,"method":"ping"}`, invalid("1", `jsonrpc must be \"2.0\"`)},
+		{"missing method", `{"jsonrpc":"2.0","id":"a"}`, invalid(`"a"`, "method is required")},
+		{
+			"batch",
+			`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"ping"},{"jsonrpc":"2.0","id":"b","method":"nope"}]`,
+			`[{"jsonrpc":"2.0","id":1,"result":{}},{"jsonrpc":"2.0","id":"b","error":{"code":-32601,"message":"Method not found"}}]`,
+		},
+		{
+			"invalid members",
+			`[1,{"foo":"boo"},{"jsonrpc":"2.0","id":3,"method":"ping"}]`,
+			`[` + invalid("null", "not a request object") + `,` + invalid("null", `jsonrpc must be \"2.0\"`) + `,{"jsonrpc":"2.0","id":3,"result":{}}]`,
+		},
+		{"initialize in batch", `[{"jsonrpc":"2.0","id":1,"method":"initialize"}]`, `[` + invalid("1", "initialize cannot be batched") + `]`},
+		{"all notifications", `[{"jsonrpc":"2.0","method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`, ``},
+	}
+
+	server := NewServer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reply interface{}
+			msg, rpcErr := ParseMessage([]byte(tt.message))
+			if rpcErr != nil {
+				reply = JSONRPCResponse{JSONRPC: "2.0", Error: rpcErr}
+			} else if out := msg.Reply(server.HandleSessionMessage(context.Background(), &Session{}, msg)); out != nil {
+				reply = out
+			}
+
+			got := ""
+			if reply != nil {
+				got = mustMarshal(reply)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandleSessionMessage_BatchOrder(t *testing.T) {
+	server := NewServer()
+	colors := []string{"red", "green", "blue", "cyan", "magenta", "yellow"}
+
+	var batch []JSONRPCRequest
+	for i, color := range colors {
+		batch = append(batch, JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      i,
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": "add_color", "arguments": map[string]interface{}{"color": color}},
+		})
+	}
+	data, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatalf("Failed to encode batch: %v", err)
+	}
+
+	msg, rpcErr := ParseMessage(data)
+	if rpcErr != nil {
+		t.Fatalf("Failed to parse batch: %v", rpcErr)
+	}
+	responses := server.HandleSessionMessage(context.Background(), &Session{}, msg)
+	if len(responses) != len(colors) {
+		t.Fatalf("Expected %d responses, got %d", len(colors), len(responses))
+	}
+	for i, response := range responses {
+		if id := string(response.ID.(json.RawMessage)); id != fmt.Sprint(i) {
+			t.Errorf("Expected response %d to answer id %d, got %s", i, i, id)
+		}
+		if text := resultText(t, response); !strings.Contains(text, colors[i]) {
+			t.Errorf("Expected response %d to be about %s, got: %s", i, colors[i], text)
+		}
+	}
+	store, err := server.backend.Namespace("")
+	if err != nil {
+		t.Fatalf("Failed to open namespace: %v", err)
+	}
+	if count := store.Count(); count != len(colors) {
+		t.Errorf("Expected %d colors, got %d", len(colors), count)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// inFlightRequest is a request of a session being handled
+type inFlightRequest struct {
+	cancel context.CancelFunc
+}
+
+// requestKey returns the form of a request ID that identifies it among the
+// session's requests, whatever JSON spelling the client used for it
+func requestKey(id interface{}) string {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return ""
+	}
+	canonical, _ := json.Marshal(value)
+	return string(canonical)
+}
+
+// track registers a request as in flight, returning the context to handle
+// it with, which notifications/cancelled cancels, and the function to call
+// when it is done
+func (sess *Session) track(ctx context.Context, id interface{}) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	request := &inFlightRequest{cancel: cancel}
+	key := requestKey(id)
+
+	sess.mutex.Lock()
+	if sess.inFlight == nil {
+		sess.inFlight = make(map[string]*inFlightRequest)
+	}
+	sess.inFlight[key] = request
+	sess.mutex.Unlock()
+
+	return ctx, func() {
+		sess.mutex.Lock()
+		// A later request may have reused the ID
+		if sess.inFlight[key] == request {
+			delete(sess.inFlight, key)
+		}
+		sess.mutex.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels the in-flight request with the given ID, if any
+func (sess *Session) cancel(id interface{}) {
+	sess.mutex.RLock()
+	request, ok := sess.inFlight[requestKey(id)]
+	sess.mutex.RUnlock()
+	if ok {
+		request.cancel()
+	}
+}
+
+// cancelAll cancels every in-flight request, as when the session closes
+func (sess *Session) cancelAll() {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	for _, request := range sess.inFlight {
+		request.cancel()
+	}
+}
+
+// handleCancelled handles notifications/cancelled. Requests that are
+// unknown or already answered are ignored, as the notification may cross
+// their response.
+func (s *Server) handleCancelled(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	if id, ok := params["requestId"]; ok && id != nil {
+		sess.cancel(id)
+	}
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// registerBlockingTool registers a tool whose calls wait for their context
+// to be cancelled, reporting each call's start on started
+func registerBlockingTool(server *Server) <-chan struct{} {
+	started := make(chan struct{}, 1)
+	server.RegisterTool(Tool{Name: "block", InputSchema: ToolSchema{Type: "object"}},
+		func(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return &ToolResult{Text: "cancelled"}, nil
+		})
+	return started
+}
+
+// callAsync handles a raw request in the background, delivering its
+// response on the returned channel
+func callAsync(server *Server, sess *Session, data string) <-chan *JSONRPCResponse {
+	responses := make(chan *JSONRPCResponse, 1)
+	msg, rpcErr := ParseMessage([]byte(data))
+	if rpcErr != nil {
+		panic(rpcErr.Message)
+	}
+	go func() {
+		responses <- server.HandleSessionRequest(context.Background(), sess, msg.Requests[0])
+	}()
+	return responses
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+func TestServer_NotificationsCancelled(t *testing.T) {
+	server := NewServer()
+	started := registerBlockingTool(server)
+	sess := &Session{}
+
+	responses := callAsync(server, sess, `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"block"}}`)
+	waitFor(t, started)
+
+	// IDs of another type name another request
+	server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]interface{}{"requestId": "7"},
+	})
+	select {
+	case response := <-responses:
+		t.Fatalf("Expected the call to carry on, got %v", response)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]interface{}{"requestId": float64(7), "reason": "user aborted"},
+	})
+	select {
+	case response := <-responses:
+		if response != nil {
+			t.Errorf("Expected no response to a cancelled request, got %v", response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the call to be cancelled")
+	}
+
+	// Cancelling requests that are done, or unknown, is harmless
+	if response := server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]interface{}{"requestId": float64(7)},
+	}); response != nil {
+		t.Errorf("Expected no response to the notification, got %v", response)
+	}
+	if len(sess.inFlight) != 0 {
+		t.Errorf("Expected no requests in flight, got %d", len(sess.inFlight))
+	}
+}
+
+func TestServer_CancelBeforeHandling(t *testing.T) {
+	server := NewServer()
+	started := registerBlockingTool(server)
+	sess := &Session{}
+
+	msg, _ := ParseMessage([]byte(`{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"block"}}`))
+	handle := server.StartSessionMessage(context.Background(), sess, msg)
+
+	// Cancelled once started, before its handler runs
+	server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]interface{}{"requestId": "slow"},
+	})
+	if responses := handle(); len(responses) != 0 {
+		t.Errorf("Expected no response to a cancelled request, got %v", responses)
+	}
+	waitFor(t, started)
+	if len(sess.inFlight) != 0 {
+		t.Errorf("Expected no requests in flight, got %d", len(sess.inFlight))
+	}
+}
+
+func TestServer_CancelledContext(t *testing.T) {
+	server := NewServer()
+	started := registerBlockingTool(server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	responses := make(chan *JSONRPCResponse, 1)
+	go func() {
+		responses <- server.HandleSessionRequest(ctx, &Session{}, JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": "block"},
+		})
+	}()
+	waitFor(t, started)
+
+	// As when the client of the transport disconnects
+	cancel()
+	if response := <-responses; response != nil {
+		t.Errorf("Expected no response once the context is cancelled, got %v", response)
+	}
+}
+
+func TestServer_CloseSessionCancels(t *testing.T) {
+	server := NewServer()
+	started := registerBlockingTool(server)
+	sess := server.OpenSession(&Session{})
+	server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize"})
+	server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+
+	responses := callAsync(server, sess, `{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"block"}}`)
+	waitFor(t, started)
+	if err := server.CloseSession(sess); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+	select {
+	case response := <-responses:
+		if response != nil {
+			t.Errorf("Expected no response, got %v", response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected closing the session to cancel its requests")
+	}
+}
+
+func TestRequestKey(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		same bool
+	}{
+		{json.RawMessage(`7`), float64(7), true},
+		{json.RawMessage(`7.0`), 7, true},
+		{json.RawMessage(` "7" `), "7", true},
+		{json.RawMessage(`7`), "7", false},
+	}
+	for _, tt := range tests {
+		if same := requestKey(tt.a) == requestKey(tt.b); same != tt.same {
+			t.Errorf("requestKey(%v) == requestKey(%v) is %v, want %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"favorite-colors-mcp/internal/color"
+	"favorite-colors-mcp/internal/storage"
+)
+
+// maxCompletionValues is the most values a completion/complete result holds
+const maxCompletionValues = 100
+
+// paletteMoods are the suggested moods of the design_palette prompt
+var paletteMoods = []string{"bold", "calm", "earthy", "elegant", "energetic", "muted", "pastel", "playful", "warm", "cool"}
+
+// handleComplete handles the completion/complete method. Besides the
+// ref/prompt and ref/resource references of the protocol, it completes tool
+// arguments named by {"type": "ref/tool", "name": ...}.
+func (s *Server) handleComplete(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	fail := func(message string) JSONRPCResponse {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: message,
+			},
+		}
+	}
+
+	params, _ := req.Params.(map[string]interface{})
+	ref, _ := params["ref"].(map[string]interface{})
+	argument, _ := params["argument"].(map[string]interface{})
+	refType, _ := ref["type"].(string)
+	name, _ := argument["name"].(string)
+	value, _ := argument["value"].(string)
+	if ref == nil || name == "" {
+		return fail("Missing ref or argument name")
+	}
+
+	store, rpcErr := s.storeFor(sess)
+	if rpcErr != nil {
+		return JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+
+	var candidates []string
+	switch refType {
+	case "ref/prompt":
+		promptName, _ := ref["name"].(string)
+		if !hasPromptArgument(promptName, name) {
+			return fail(fmt.Sprintf("Unknown argument %q of prompt %q", name, promptName))
+		}
+		candidates = promptCandidates(store, name)
+	case "ref/resource":
+		uri, _ := ref["uri"].(string)
+		if uri != favoriteURITemplate || name != "id" {
+			return fail(fmt.Sprintf("Unknown variable %q of resource template %q", name, uri))
+		}
+		for _, fav := range store.Favorites() {
+			candidates = append(candidates, fav.ID)
+		}
+	case "ref/tool":
+		toolName, _ := ref["name"].(string)
+		registered, ok := s.lookupTool(sess, toolName)
+		if !ok {
+			return fail(fmt.Sprintf("Unknown tool %q", toolName))
+		}
+		if _, ok := registered.tool.InputSchema.Properties[name]; !ok {
+			return fail(fmt.Sprintf("Unknown argument %q of tool %q", name, toolName))
+		}
+		candidates = toolCandidates(store, toolName, name)
+	default:
+		return fail(fmt.Sprintf("Unknown reference type %q", refType))
+	}
+
+	values := rankCompletions(candidates, value)
+	total := len(values)
+	if total > maxCompletionValues {
+		values = values[:maxCompletionValues]
+	}
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"completion": map[string]interface{}{
+				"values":  values,
+				"total":   total,
+				"hasMore": total > len(values),
+			},
+		},
+	}
+}
+
+// hasPromptArgument reports whether the prompt called name takes argument
+func hasPromptArgument(name, argument string) bool {
+	for _, builtin := range builtinPrompts {
+		if builtin.prompt.Name != name {
+			continue
+		}
+		for _, arg := range builtin.prompt.Arguments {
+			if arg.Name == argument {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// promptCandidates returns the values a prompt argument may take
+func promptCandidates(store storage.Store, argument string) []string {
+	switch argument {
+	case "background":
+		return append(favoriteNames(store), color.NamedColors()...)
+	case "mood":
+		return paletteMoods
+	case "size":
+		var sizes []string
+		for n := 2; n <= 12; n++ {
+			sizes = append(sizes, strconv.Itoa(n))
+		}
+		return sizes
+	}
+	return nil
+}
+
+// toolCandidates returns the values an argument of a tool may take
+func toolCandidates(store storage.Store, tool, argument string) []string {
+	switch {
+	case tool == "remove_color" && argument == "color":
+		return favoriteNames(store)
+	case argument == "color" || argument == "colors":
+		return color.NamedColors()
+	case argument == "tags":
+		var tags []string
+		for _, fav := range store.Favorites() {
+			tags = append(tags, fav.Tags...)
+		}
+		return tags
+	}
+	return nil
+}
+
+// favoriteNames returns the names of the store's favorites, followed by
+// the values of those whose name is another spelling
+func favoriteNames(store storage.Store) []string {
+	favorites := store.Favorites()
+	names := make([]string, 0, 2*len(favorites))
+	for _, fav := range favorites {
+		names = append(names, fav.Name)
+	}
+	for _, fav := range favorites {
+		if !strings.EqualFold(fav.Name, fav.Value) {
+			names = append(names, fav.Value)
+		}
+	}
+	return names
+}
+
+// rankCompletions returns the candidates matching value, best first: those
+// it prefixes, then those with a word it prefixes, then those containing it,
+// then those containing its characters in order, the earliest and tightest
+// matches first. Other ties keep the candidates' order. Matching ignores
+// case, and repeated candidates are dropped.
+func rankCompletions(candidates []string, value string) []string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	seen := make(map[string]bool, len(candidates))
+	var matches []completionMatch
+	for _, candidate := range candidates {
+		key := strings.ToLower(candidate)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if m, ok := matchCompletion(key, value); ok {
+			m.value = candidate
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].less(matches[j]) })
+
+	values := make([]string, len(matches))
+	for i, m := range matches {
+		values[i] = m.value
+	}
+	return values
+}
+
+// completionMatch is where a completion value matched
+type completionMatch struct {
+	value string
+	// kind is 0 for a prefix, 1 for a word prefix, 2 for a substring and 3
+	// for a subsequence
+	kind int
+	// start and end are the bounds of the matched text
+	start, end int
+}
+
+func (m completionMatch) less(o completionMatch) bool {
+	if m.kind != o.kind {
+		return m.kind < o.kind
+	}
+	if m.start != o.start {
+		return m.start < o.start
+	}
+	return m.end-m.start < o.end-o.start
+}
+
+// matchCompletion matches value against the lower-case candidate
+func matchCompletion(candidate, value string) (completionMatch, bool) {
+	if strings.HasPrefix(candidate, value) {
+		return completionMatch{kind: 0, end: len(value)}, true
+	}
+	if i := strings.Index(candidate, value); i >= 0 {
+		kind := 2
+		if strings.ContainsRune(" -_", rune(candidate[i-1])) {
+			kind = 1
+		}
+		return completionMatch{kind: kind, start: i, end: i + len(value)}, true
+	}
+
+	start, end := -1, 0
+	for _, r := range value {
+		i := strings.IndexRune(candidate[end:], r)
+		if i < 0 {
+			return completionMatch{}, false
+		}
+		if start < 0 {
+			start = end + i
+		}
+		end += i + utf8.RuneLen(r)
+	}
+	return completionMatch{kind: 3, start: start, end: end}, true
+}
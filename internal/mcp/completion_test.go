@@ -0,0 +1,106 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// complete returns the completion of an argument of ref
+func complete(t *testing.T, server *Server, sess *Session, ref map[string]interface{}, name, value string) map[string]interface{} {
+	t.Helper()
+	response := request(t, server, sess, "completion/complete", map[string]interface{}{
+		"ref":      ref,
+		"argument": map[string]interface{}{"name": name, "value": value},
+	})
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got %v", response.Error)
+	}
+	return response.Result.(map[string]interface{})["completion"].(map[string]interface{})
+}
+
+func TestServer_Complete(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "Sea Green", "tags": []interface{}{"nature"}})
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "salmon"})
+
+	removeColor := map[string]interface{}{"type": "ref/tool", "name": "remove_color"}
+	completion := complete(t, server, sess, removeColor, "color", "s")
+	if values := completion["values"].([]string); !reflect.DeepEqual(values, []string{"Sea Green", "salmon"}) {
+		t.Errorf("Expected the favorites, got %v", values)
+	}
+	completion = complete(t, server, sess, removeColor, "color", "gre")
+	if values := completion["values"].([]string); !reflect.DeepEqual(values, []string{"Sea Green"}) {
+		t.Errorf("Expected favorites by word, got %v", values)
+	}
+
+	// Named colors are ranked prefixes first, and capped
+	addColor := map[string]interface{}{"type": "ref/tool", "name": "add_color"}
+	completion = complete(t, server, sess, addColor, "color", "gren")
+	values := completion["values"].([]string)
+	if len(values) == 0 || values[0] != "green" {
+		t.Errorf("Expected green first, got %v", values)
+	}
+	completion = complete(t, server, sess, addColor, "color", "")
+	if len(completion["values"].([]string)) != maxCompletionValues || completion["total"].(int) <= maxCompletionValues || completion["hasMore"] != true {
+		t.Errorf("Expected the named colors capped, got %d of %v", len(completion["values"].([]string)), completion["total"])
+	}
+	completion = complete(t, server, sess, addColor, "tags", "n")
+	if values := completion["values"].([]string); !reflect.DeepEqual(values, []string{"nature"}) {
+		t.Errorf("Expected the tags in use, got %v", values)
+	}
+
+	prompt := map[string]interface{}{"type": "ref/prompt", "name": "accessible_text_colors"}
+	completion = complete(t, server, sess, prompt, "background", "salm")
+	if values := completion["values"].([]string); !reflect.DeepEqual(values, []string{"salmon", "darksalmon", "lightsalmon"}) {
+		t.Errorf("Expected favorites and named colors once, got %v", values)
+	}
+
+	template := map[string]interface{}{"type": "ref/resource", "uri": "colors://favorites/{id}"}
+	completion = complete(t, server, sess, template, "id", "")
+	if completion["total"] != 2 {
+		t.Errorf("Expected the favorite IDs, got %v", completion)
+	}
+
+	tests := []map[string]interface{}{
+		{"type": "ref/tool", "name": "nope"},
+		{"type": "ref/tool", "name": "list_namespaces"},
+		{"type": "ref/prompt", "name": "describe_taste"},
+		{"type": "ref/resource", "uri": "colors://favorites"},
+		{"type": "ref/nope"},
+	}
+	for _, ref := range tests {
+		response := request(t, server, sess, "completion/complete", map[string]interface{}{
+			"ref":      ref,
+			"argument": map[string]interface{}{"name": "color", "value": ""},
+		})
+		if response.Error == nil || response.Error.Code != -32602 {
+			t.Errorf("Expected invalid params for %v, got %+v", ref, response)
+		}
+	}
+}
+
+func TestRankCompletions(t *testing.T) {
+	candidates := []string{"darkgreen", "green", "greenyellow", "Green", "lawngreen", "grey"}
+	want := []string{"green", "greenyellow", "darkgreen", "lawngreen"}
+	if got := rankCompletions(candidates, "GREEN"); !reflect.DeepEqual(got, want) {
+		t.Errorf("rankCompletions = %v, want %v", got, want)
+	}
+	if got := rankCompletions(candidates, "dkgn"); !reflect.DeepEqual(got, []string{"darkgreen"}) {
+		t.Errorf("Expected a fuzzy match, got %v", got)
+	}
+}
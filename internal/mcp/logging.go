@@ -0,0 +1,144 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// LogLevel is the severity of a log event, as in RFC 5424 and MCP
+type LogLevel int
+
+// Log levels, from least to most severe. The zero LogLevel is no level.
+const (
+	LogDebug LogLevel = iota + 1
+	LogInfo
+	LogNotice
+	LogWarning
+	LogError
+	LogCritical
+	LogAlert
+	LogEmergency
+)
+
+var logLevelNames = []string{"", "debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// stderrLogLevel is the least severe level written to stderr
+const stderrLogLevel = LogInfo
+
+// String returns the MCP name of the level
+func (l LogLevel) String() string {
+	if l < LogDebug || l > LogEmergency {
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
+	return logLevelNames[l]
+}
+
+// ParseLogLevel returns the level of an MCP level name
+func ParseLogLevel(name string) (LogLevel, bool) {
+	for l := LogDebug; l <= LogEmergency; l++ {
+		if logLevelNames[l] == name {
+			return l, true
+		}
+	}
+	return 0, false
+}
+
+// logLevel returns the least severe level the session's client asked for,
+// or 0 if it did not ask for log messages
+func (sess *Session) logLevel() LogLevel {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	return sess.clientLogLevel
+}
+
+// handleSetLevel handles the logging/setLevel method
+func (s *Server) handleSetLevel(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	name, _ := params["level"].(string)
+	level, ok := ParseLogLevel(name)
+	if !ok {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Invalid log level %q", name),
+			},
+		}
+	}
+
+	sess.mutex.Lock()
+	sess.clientLogLevel = level
+	sess.mutex.Unlock()
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
+}
+
+// logEvent logs an event of a session: to stderr at stderrLogLevel and
+// above, and to the session's client as notifications/message at the level
+// it set with logging/setLevel and above. fields structure the event; the
+// client receives them along with msg as the message's data.
+func (s *Server) logEvent(ctx context.Context, sess *Session, level LogLevel, logger, msg string, fields map[string]interface{}) {
+	if level >= stderrLogLevel {
+		log.Printf("[%s] %s: %s%s", level, logger, msg, formatLogFields(fields))
+	}
+
+	if sess == nil || !sess.Supports(FeatureLogging) {
+		return
+	}
+	if min := sess.logLevel(); min == 0 || level < min {
+		return
+	}
+	data := make(map[string]interface{}, len(fields)+1)
+	for key, value := range fields {
+		data[key] = value
+	}
+	data["message"] = msg
+	// Delivery is best effort; the event is on stderr when it matters
+	_ = sess.notifyRelated(ctx, "notifications/message", map[string]interface{}{
+		"level":  level.String(),
+		"logger": logger,
+		"data":   data,
+	})
+}
+
+// formatLogFields formats fields as space-separated key=value pairs in key
+// order, with a leading space
+func formatLogFields(fields map[string]interface{}) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		value := fields[key]
+		if raw, ok := value.(json.RawMessage); ok {
+			value = string(raw)
+		}
+		fmt.Fprintf(&b, " %s=%v", key, value)
+	}
+	return b.String()
+}
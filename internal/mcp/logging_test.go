@@ -0,0 +1,100 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// logMessages returns the notifications/message params sent so far, and
+// forgets them
+func (r *recorder) logMessages() []map[string]interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var messages []map[string]interface{}
+	for _, msg := range r.messages {
+		if msg.Method == "notifications/message" {
+			messages = append(messages, msg.Params.(map[string]interface{}))
+		}
+	}
+	r.messages = nil
+	return messages
+}
+
+func TestServer_Logging(t *testing.T) {
+	var stderr bytes.Buffer
+	log.SetOutput(&stderr)
+	defer log.SetOutput(os.Stderr)
+
+	server := NewServer()
+	sess, sent := openSubscriber(t, server, "")
+
+	// Nothing is sent until the client sets a level
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "red"})
+	if messages := sent.logMessages(); len(messages) != 0 {
+		t.Fatalf("Expected no log messages, got %v", messages)
+	}
+	if !strings.Contains(stderr.String(), "[info] storage: Added color") {
+		t.Errorf("Expected the mutation on stderr, got %q", stderr.String())
+	}
+
+	if response := request(t, server, sess, "logging/setLevel", map[string]interface{}{"level": "info"}); response.Error != nil {
+		t.Fatalf("Failed to set the level: %v", response.Error)
+	}
+	callTool(t, server, sess, "remove_color", map[string]interface{}{"color": "red"})
+	messages := sent.logMessages()
+	if len(messages) != 1 || messages[0]["level"] != "info" || messages[0]["logger"] != "storage" {
+		t.Fatalf("Expected the removal only, got %v", messages)
+	}
+	if data := messages[0]["data"].(map[string]interface{}); data["message"] != "Removed color" || len(data["ids"].([]string)) != 1 {
+		t.Errorf("Expected the removed ID in the data, got %v", data)
+	}
+
+	// Debug messages include request dispatch and client errors
+	request(t, server, sess, "logging/setLevel", map[string]interface{}{"level": "debug"})
+	sent.logMessages()
+	request(t, server, sess, "nope", nil)
+	messages = sent.logMessages()
+	if len(messages) != 2 || messages[0]["level"] != "debug" || messages[1]["data"].(map[string]interface{})["code"] != -32601 {
+		t.Errorf("Expected the request and its failure, got %v", messages)
+	}
+	if strings.Contains(stderr.String(), "nope") {
+		t.Errorf("Expected no debug messages on stderr, got %q", stderr.String())
+	}
+
+	response := request(t, server, sess, "logging/setLevel", map[string]interface{}{"level": "verbose"})
+	if response.Error == nil || response.Error.Code != -32602 {
+		t.Errorf("Expected an invalid level to be refused, got %+v", response)
+	}
+	response = request(t, server, &Session{}, "initialize", map[string]interface{}{"protocolVersion": LatestProtocolVersion})
+	if capabilities := response.Result.(map[string]interface{})["capabilities"].(ServerCapabilities); capabilities.Logging == nil {
+		t.Error("Expected the logging capability")
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for l := LogDebug; l <= LogEmergency; l++ {
+		if parsed, ok := ParseLogLevel(l.String()); !ok || parsed != l {
+			t.Errorf("ParseLogLevel(%q) = %v, %v", l.String(), parsed, ok)
+		}
+	}
+	if _, ok := ParseLogLevel("Info"); ok {
+		t.Error("Expected level names to be case sensitive")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "context"
+
+type senderContextKey struct{}
+
+// WithSender returns a context in which the messages related to a request,
+// such as its progress notifications, are sent with send instead of the
+// session's sender. Transports use it to deliver them on the stream that
+// carries the request's response.
+func WithSender(ctx context.Context, send Sender) context.Context {
+	return context.WithValue(ctx, senderContextKey{}, send)
+}
+
+// notifyRelated sends a notification related to the request of ctx
+func (sess *Session) notifyRelated(ctx context.Context, method string, params interface{}) error {
+	send, ok := ctx.Value(senderContextKey{}).(Sender)
+	if !ok {
+		return sess.Notify(method, params)
+	}
+	if sess.Closed() {
+		return ErrNotConnected
+	}
+	return send(JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// progressToken returns the _meta.progressToken of a request's params, or
+// nil if the client did not ask for progress
+func progressToken(params map[string]interface{}) interface{} {
+	meta, _ := params["_meta"].(map[string]interface{})
+	token := meta["progressToken"]
+	switch token.(type) {
+	case string, float64:
+		return token
+	}
+	return nil
+}
+
+// ReportProgress sends notifications/progress for the tool call of ctx, if
+// its client asked for progress by giving a progress token. progress must
+// increase with every report; total is 0 when unknown, and message may be
+// empty. Failures to deliver the notification are returned, but handlers
+// can usually carry on regardless.
+func ReportProgress(ctx context.Context, progress, total float64, message string) error {
+	call, ok := ctx.Value(callContextKey{}).(callContext)
+	if !ok || call.progressToken == nil {
+		return nil
+	}
+
+	params := map[string]interface{}{
+		"progressToken": call.progressToken,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" && call.session.Supports(FeatureProgressMessage) {
+		params["message"] = message
+	}
+	return call.session.notifyRelated(ctx, "notifications/progress", params)
+}
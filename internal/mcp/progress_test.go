@@ -0,0 +1,111 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// recorder collects the messages sent to a session
+type recorder struct {
+	mutex    sync.Mutex
+	messages []JSONRPCRequest
+}
+
+func (r *recorder) send(msg JSONRPCRequest) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func importColors(t *testing.T, ctx context.Context, server *Server, sess *Session, meta map[string]interface{}) {
+	t.Helper()
+	params := map[string]interface{}{
+		"name":      "import_colors",
+		"arguments": map[string]interface{}{"colors": []interface{}{"red", "green", "red"}},
+	}
+	if meta != nil {
+		params["_meta"] = meta
+	}
+	response := server.HandleSessionRequest(ctx, sess, JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got %v", response.Error)
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+	var sent recorder
+	sess.SetSender(sent.send)
+
+	importColors(t, context.Background(), server, sess, map[string]interface{}{"progressToken": "import-1"})
+	if len(sent.messages) != 3 {
+		t.Fatalf("Expected a progress notification per color, got %d", len(sent.messages))
+	}
+	for i, msg := range sent.messages {
+		params := msg.Params.(map[string]interface{})
+		if msg.Method != "notifications/progress" || params["progressToken"] != "import-1" {
+			t.Errorf("Expected progress for the request's token, got %+v", msg)
+		}
+		if params["progress"] != float64(i+1) || params["total"] != float64(3) || params["message"] == nil {
+			t.Errorf("Expected progress %d of 3 with a message, got %v", i+1, params)
+		}
+	}
+
+	// Without a token there is no progress
+	sent.messages = nil
+	importColors(t, context.Background(), server, sess, nil)
+	if len(sent.messages) != 0 {
+		t.Errorf("Expected no progress without a token, got %v", sent.messages)
+	}
+}
+
+func TestReportProgress_RequestSender(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+	var session, stream recorder
+	sess.SetSender(session.send)
+
+	// Progress goes where the transport sends the request's response
+	ctx := WithSender(context.Background(), stream.send)
+	importColors(t, ctx, server, sess, map[string]interface{}{"progressToken": float64(42)})
+	if len(session.messages) != 0 || len(stream.messages) != 3 {
+		t.Fatalf("Expected progress on the request's stream only, got %d and %d", len(session.messages), len(stream.messages))
+	}
+	if token := stream.messages[0].Params.(map[string]interface{})["progressToken"]; token != float64(42) {
+		t.Errorf("Expected the numeric token echoed, got %v", token)
+	}
+}
+
+func TestReportProgress_ProtocolVersion(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+	if err := sess.SetProtocolVersion(ProtocolVersion20241105); err != nil {
+		t.Fatal(err)
+	}
+	var sent recorder
+	sess.SetSender(sent.send)
+
+	importColors(t, context.Background(), server, sess, map[string]interface{}{"progressToken": "t"})
+	for _, msg := range sent.messages {
+		if _, ok := msg.Params.(map[string]interface{})["message"]; ok {
+			t.Errorf("Expected no progress message before 2025-03-26, got %v", msg.Params)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"favorite-colors-mcp/internal/color"
+	"favorite-colors-mcp/internal/storage"
+)
+
+// builtinPrompt is a prompt together with the function that fills it in
+// from the session's favorites and the validated arguments
+type builtinPrompt struct {
+	prompt Prompt
+	build  func(favorites []storage.Favorite, args map[string]string) (string, *JSONRPCError)
+}
+
+// builtinPrompts are the prompts of the server, in the order they are
+// listed. Each prompt's messages are the favorites list as an embedded
+// resource, followed by the text build returns.
+var builtinPrompts = []builtinPrompt{
+	{
+		prompt: Prompt{
+			Name:        "design_palette",
+			Description: "Design a color palette from your favorite colors",
+			Arguments: []PromptArgument{
+				{Name: "size", Description: "Number of colors in the palette, from 2 to 12 (default 5)"},
+				{Name: "mood", Description: "Mood the palette should convey, such as calm or energetic"},
+			},
+		},
+		build: designPalettePrompt,
+	},
+	{
+		prompt: Prompt{
+			Name:        "accessible_text_colors",
+			Description: "Pick text colors from your favorites that are readable on a background color",
+			Arguments: []PromptArgument{
+				{Name: "background", Description: "The background color, in any CSS color syntax", Required: true},
+			},
+		},
+		build: accessibleTextColorsPrompt,
+	},
+	{
+		prompt: Prompt{
+			Name:        "describe_taste",
+			Description: "Describe your taste in color from your favorites",
+		},
+		build: describeTastePrompt,
+	},
+}
+
+// handlePromptsList handles the prompts/list method
+func (s *Server) handlePromptsList(req JSONRPCRequest) JSONRPCResponse {
+	prompts := make([]Prompt, len(builtinPrompts))
+	for i, builtin := range builtinPrompts {
+		prompts[i] = builtin.prompt
+	}
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"prompts": prompts,
+		},
+	}
+}
+
+// handlePromptsGet handles the prompts/get method
+func (s *Server) handlePromptsGet(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	fail := func(message string) JSONRPCResponse {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: message,
+			},
+		}
+	}
+
+	params, _ := req.Params.(map[string]interface{})
+	name, _ := params["name"].(string)
+	var builtin *builtinPrompt
+	for i := range builtinPrompts {
+		if builtinPrompts[i].prompt.Name == name {
+			builtin = &builtinPrompts[i]
+		}
+	}
+	if builtin == nil {
+		return fail(fmt.Sprintf("Unknown prompt %q", name))
+	}
+
+	rawArgs, _ := params["arguments"].(map[string]interface{})
+	args := make(map[string]string, len(rawArgs))
+	for key, value := range rawArgs {
+		text, ok := value.(string)
+		if !ok {
+			return fail(fmt.Sprintf("Argument %q must be a string", key))
+		}
+		args[key] = text
+	}
+	for _, arg := range builtin.prompt.Arguments {
+		if arg.Required && strings.TrimSpace(args[arg.Name]) == "" {
+			return fail(fmt.Sprintf("Argument %q is required", arg.Name))
+		}
+	}
+
+	store, rpcErr := s.storeFor(sess)
+	if rpcErr != nil {
+		return JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	favorites := store.Favorites()
+	text, rpcErr := builtin.build(favorites, args)
+	if rpcErr != nil {
+		return JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"description": builtin.prompt.Description,
+			"messages": []PromptMessage{
+				{
+					Role: "user",
+					Content: EmbeddedResource{
+						Type: "resource",
+						Resource: ResourceContents{
+							URI:      favoritesURI,
+							MimeType: "text/markdown",
+							Text:     favoritesMarkdown(favorites),
+						},
+					},
+				},
+				{
+					Role:    "user",
+					Content: TextContent{Type: "text", Text: text},
+				},
+			},
+		},
+	}
+}
+
+// invalidArgument is the error of a prompt argument that cannot be used
+func invalidArgument(message string) *JSONRPCError {
+	return &JSONRPCError{
+		Code:    -32602,
+		Message: message,
+	}
+}
+
+func designPalettePrompt(_ []storage.Favorite, args map[string]string) (string, *JSONRPCError) {
+	size := 5
+	if value := strings.TrimSpace(args["size"]); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 2 || n > 12 {
+			return "", invalidArgument("Argument \"size\" must be a number from 2 to 12")
+		}
+		size = n
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Design a palette of %d colors that work together, based on my favorite colors above.", size)
+	if mood := strings.TrimSpace(args["mood"]); mood != "" {
+		fmt.Fprintf(&b, " The palette should feel %s.", mood)
+	}
+	b.WriteString(" Use my favorites where they fit and suggest new colors where they do not." +
+		" For each color, give its hex code and its role, such as background, surface, primary, accent or text.")
+	return b.String(), nil
+}
+
+func accessibleTextColorsPrompt(favorites []storage.Favorite, args map[string]string) (string, *JSONRPCError) {
+	background, err := color.Parse(args["background"])
+	if err != nil {
+		return "", invalidArgument(fmt.Sprintf("Argument \"background\" is not a CSS color: %q", args["background"]))
+	}
+
+	type contrast struct {
+		fav   storage.Favorite
+		ratio float64
+	}
+	var contrasts []contrast
+	for _, fav := range favorites {
+		// Favorites that are not CSS colors have no contrast
+		if c, err := color.Parse(fav.Value); err == nil {
+			contrasts = append(contrasts, contrast{fav, color.ContrastRatio(c, background)})
+		}
+	}
+	sort.SliceStable(contrasts, func(i, j int) bool { return contrasts[i].ratio > contrasts[j].ratio })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "My background color is %s (%s).", args["background"], background.Hex())
+	if len(contrasts) > 0 {
+		b.WriteString(" Against it, my favorite colors have these WCAG 2 contrast ratios:\n\n")
+		for _, c := range contrasts {
+			level := "fails"
+			switch {
+			case c.ratio >= 7:
+				level = "AAA"
+			case c.ratio >= 4.5:
+				level = "AA"
+			case c.ratio >= 3:
+				level = "AA for large text only"
+			}
+			fmt.Fprintf(&b, "- %s (%s): %.2f:1, %s\n", c.fav.Name, c.fav.Value, c.ratio, level)
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString(" None of my favorites is a color I can measure against it.\n\n")
+	}
+	b.WriteString("Pick text colors for this background, preferring my favorites that reach 4.5:1 for body text" +
+		" and 3:1 for large text. Where none do, suggest the closest readable variants of my favorites with their hex codes.")
+	return b.String(), nil
+}
+
+func describeTastePrompt(_ []storage.Favorite, _ map[string]string) (string, *JSONRPCError) {
+	return "Describe my taste in color from my favorite colors above: the hues, lightness and saturation I lean towards," +
+		" the moods they evoke, and what my notes and tags say about them." +
+		" If I have no favorites yet, say so and ask me about colors I like.", nil
+}
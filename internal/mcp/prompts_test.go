@@ -0,0 +1,90 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServer_PromptsList(t *testing.T) {
+	server := NewServer()
+	response := request(t, server, &Session{}, "prompts/list", nil)
+	prompts := response.Result.(map[string]interface{})["prompts"].([]Prompt)
+	if len(prompts) != 3 {
+		t.Fatalf("Expected 3 prompts, got %+v", prompts)
+	}
+	if prompts[1].Name != "accessible_text_colors" || len(prompts[1].Arguments) != 1 || !prompts[1].Arguments[0].Required {
+		t.Errorf("Expected a required background argument, got %+v", prompts[1])
+	}
+
+	response = request(t, server, &Session{}, "initialize", map[string]interface{}{"protocolVersion": LatestProtocolVersion})
+	if capabilities := response.Result.(map[string]interface{})["capabilities"].(ServerCapabilities); capabilities.Prompts == nil {
+		t.Error("Expected the prompts capability")
+	}
+}
+
+func TestServer_PromptsGet(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "black"})
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "yellow"})
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "ocean breeze"})
+
+	response := request(t, server, sess, "prompts/get", map[string]interface{}{
+		"name":      "accessible_text_colors",
+		"arguments": map[string]interface{}{"background": "white"},
+	})
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got %v", response.Error)
+	}
+	messages := response.Result.(map[string]interface{})["messages"].([]PromptMessage)
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %+v", messages)
+	}
+	embedded, ok := messages[0].Content.(EmbeddedResource)
+	if !ok || embedded.Resource.URI != "colors://favorites" || !strings.Contains(embedded.Resource.Text, "**yellow**") {
+		t.Errorf("Expected the favorites embedded, got %+v", messages[0].Content)
+	}
+	text := messages[1].Content.(TextContent).Text
+	if !strings.Contains(text, "- black (#000000): 21.00:1, AAA") || !strings.Contains(text, "yellow (#ffff00): 1.07:1, fails") {
+		t.Errorf("Expected contrast ratios of the favorites, got %q", text)
+	}
+	if strings.Index(text, "black") > strings.Index(text, "yellow") || strings.Contains(text, "ocean breeze") {
+		t.Errorf("Expected measurable favorites by contrast, got %q", text)
+	}
+
+	response = request(t, server, sess, "prompts/get", map[string]interface{}{
+		"name":      "design_palette",
+		"arguments": map[string]interface{}{"size": "3", "mood": "calm"},
+	})
+	text = response.Result.(map[string]interface{})["messages"].([]PromptMessage)[1].Content.(TextContent).Text
+	if !strings.Contains(text, "palette of 3 colors") || !strings.Contains(text, "feel calm") {
+		t.Errorf("Expected the arguments in the prompt, got %q", text)
+	}
+
+	tests := []map[string]interface{}{
+		{"name": "nope"},
+		{"name": "accessible_text_colors"},
+		{"name": "accessible_text_colors", "arguments": map[string]interface{}{"background": "nope"}},
+		{"name": "design_palette", "arguments": map[string]interface{}{"size": "20"}},
+		{"name": "design_palette", "arguments": map[string]interface{}{"size": float64(3)}},
+	}
+	for _, params := range tests {
+		if response := request(t, server, sess, "prompts/get", params); response.Error == nil || response.Error.Code != -32602 {
+			t.Errorf("Expected invalid params for %v, got %+v", params, response)
+		}
+	}
+}
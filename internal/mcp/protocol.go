@@ -14,7 +14,10 @@
 
 package mcp
 
-// JSONRPCRequest represents a JSON-RPC 2.0 request
+import "encoding/json"
+
+// JSONRPCRequest represents a JSON-RPC 2.0 request, or a notification if it
+// has no ID
 type JSONRPCRequest struct {
 	JSONRPC string      `json:"jsonrpc"`
 	ID      interface{} `json:"id,omitempty"`
@@ -22,10 +25,35 @@ type JSONRPCRequest struct {
 	Params  interface{} `json:"params,omitempty"`
 }
 
+// UnmarshalJSON decodes a request, keeping its id as the raw JSON sent so
+// that responses echo it exactly. A missing id leaves ID nil.
+func (r *JSONRPCRequest) UnmarshalJSON(data []byte) error {
+	type request JSONRPCRequest
+	var raw struct {
+		request
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = JSONRPCRequest(raw.request)
+	if raw.ID != nil {
+		r.ID = raw.ID
+	}
+	return nil
+}
+
+// IsNotification reports whether the request is a notification, which must
+// not be answered
+func (r JSONRPCRequest) IsNotification() bool {
+	return r.ID == nil
+}
+
 // JSONRPCResponse represents a JSON-RPC 2.0 response
 type JSONRPCResponse struct {
 	JSONRPC string        `json:"jsonrpc"`
-	ID      interface{}   `json:"id,omitempty"`
+	ID      interface{}   `json:"id"`
 	Result  interface{}   `json:"result,omitempty"`
 	Error   *JSONRPCError `json:"error,omitempty"`
 }
@@ -37,6 +65,11 @@ type JSONRPCError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// Error implements error, so handlers can fail a request with a JSONRPCError
+func (e *JSONRPCError) Error() string {
+	return e.Message
+}
+
 // ServerInfo contains MCP server information
 type ServerInfo struct {
 	Name    string `json:"name"`
@@ -45,7 +78,59 @@ type ServerInfo struct {
 
 // ServerCapabilities defines what the server can do
 type ServerCapabilities struct {
-	Tools struct{} `json:"tools"`
+	Tools       ToolsCapability        `json:"tools"`
+	Resources   *ResourcesCapability   `json:"resources,omitempty"`
+	Prompts     *PromptsCapability     `json:"prompts,omitempty"`
+	Completions *CompletionsCapability `json:"completions,omitempty"`
+	Logging     *LoggingCapability     `json:"logging,omitempty"`
+}
+
+// ToolsCapability describes the server's support for tools
+type ToolsCapability struct {
+	// ListChanged is set if the server notifies clients when its tools
+	// change
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// ResourcesCapability describes the server's support for resources
+type ResourcesCapability struct {
+	// Subscribe is set if clients can subscribe to resource updates
+	Subscribe bool `json:"subscribe,omitempty"`
+}
+
+// PromptsCapability describes the server's support for prompts
+type PromptsCapability struct{}
+
+// CompletionsCapability describes the server's support for argument
+// completion
+type CompletionsCapability struct{}
+
+// LoggingCapability describes the server's support for sending log
+// messages to clients
+type LoggingCapability struct{}
+
+// Resource is a piece of context the server offers clients to read
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate describes a family of resources by an RFC 6570 URI
+// template
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the text of a resource in one representation
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
 }
 
 // Tool represents an MCP tool definition
@@ -53,6 +138,23 @@ type Tool struct {
 	Name        string     `json:"name"`
 	Description string     `json:"description"`
 	InputSchema ToolSchema `json:"inputSchema"`
+	// OutputSchema describes the structuredContent of the tool's results
+	OutputSchema *ToolSchema `json:"outputSchema,omitempty"`
+	// Annotations describe the tool's behavior to clients
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+
+	// adminOnly tools are only listed to and callable by admin sessions
+	adminOnly bool
+}
+
+// ToolAnnotations are hints about the behavior of a tool. Unset hints take
+// the defaults of the MCP specification.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
 }
 
 // ToolSchema defines the input schema for a tool
@@ -60,4 +162,41 @@ type ToolSchema struct {
 	Type       string                 `json:"type"`
 	Properties map[string]interface{} `json:"properties,omitempty"`
 	Required   []string               `json:"required,omitempty"`
+	// AdditionalProperties is false to reject properties not listed in
+	// Properties, or a schema they must match. Nil allows any.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// Prompt is a template of messages the server offers clients to start
+// conversations with
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument that fills in a prompt
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is a message of a prompt. Content is a TextContent or an
+// EmbeddedResource.
+type PromptMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// TextContent is content made of text
+type TextContent struct {
+	Type string `json:"type"` // "text"
+	Text string `json:"text"`
+}
+
+// EmbeddedResource is content carrying the contents of a resource
+type EmbeddedResource struct {
+	Type     string           `json:"type"` // "resource"
+	Resource ResourceContents `json:"resource"`
 }
@@ -0,0 +1,54 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRPCRequest_PreservesID(t *testing.T) {
+	server := NewServer()
+
+	for _, id := range []string{`0`, `7`, `"abc"`, `""`, `12345678901234567890`, `1.50`, `null`} {
+		var req JSONRPCRequest
+		if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","id":`+id+`,"method":"ping"}`), &req); err != nil {
+			t.Fatalf("Failed to decode request with id %s: %v", id, err)
+		}
+		if req.IsNotification() {
+			t.Errorf("Expected id %s to make a request", id)
+			continue
+		}
+
+		data, err := json.Marshal(server.HandleRequest(context.Background(), req))
+		if err != nil {
+			t.Fatalf("Failed to encode response: %v", err)
+		}
+		if want := `{"jsonrpc":"2.0","id":` + id + `,"result":{}}`; string(data) != want {
+			t.Errorf("Expected %s, got %s", want, data)
+		}
+	}
+}
+
+func TestJSONRPCRequest_Notification(t *testing.T) {
+	var req JSONRPCRequest
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`), &req); err != nil {
+		t.Fatalf("Failed to decode notification: %v", err)
+	}
+	if !req.IsNotification() {
+		t.Error("Expected a request without an id to be a notification")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"favorite-colors-mcp/internal/storage"
+)
+
+// URIs of the favorites resources
+const (
+	// favoritesURI is the resource of the whole favorites list
+	favoritesURI = "colors://favorites"
+	// favoriteURIPrefix prefixes the ID of a favorite in its resource URI
+	favoriteURIPrefix = favoritesURI + "/"
+	// favoriteURITemplate describes the resources of single favorites
+	favoriteURITemplate = favoriteURIPrefix + "{id}"
+)
+
+// favoritesResource describes the resource of the whole favorites list
+var favoritesResource = Resource{
+	URI:         favoritesURI,
+	Name:        "Favorite colors",
+	Description: "All your favorite colors, as JSON and as a Markdown list",
+	MimeType:    "application/json",
+}
+
+// favoriteResource describes the resource of a favorite
+func favoriteResource(fav storage.Favorite) Resource {
+	return Resource{
+		URI:         favoriteURIPrefix + fav.ID,
+		Name:        fav.Name,
+		Description: fmt.Sprintf("Favorite color %s (%s)", fav.Name, fav.Value),
+		MimeType:    "application/json",
+	}
+}
+
+// handleResourcesList handles the resources/list method: the favorites
+// list, then each favorite
+func (s *Server) handleResourcesList(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	store, rpcErr := s.storeFor(sess)
+	if rpcErr != nil {
+		return JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+
+	favorites := store.Favorites()
+	resources := make([]Resource, 0, len(favorites)+1)
+	resources = append(resources, favoritesResource)
+	for _, fav := range favorites {
+		resources = append(resources, favoriteResource(fav))
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"resources": resources,
+		},
+	}
+}
+
+// handleResourceTemplatesList handles the resources/templates/list method
+func (s *Server) handleResourceTemplatesList(req JSONRPCRequest) JSONRPCResponse {
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"resourceTemplates": []ResourceTemplate{{
+				URITemplate: favoriteURITemplate,
+				Name:        "Favorite color",
+				Description: "A single favorite color by its ID",
+				MimeType:    "application/json",
+			}},
+		},
+	}
+}
+
+// handleResourcesRead handles the resources/read method
+func (s *Server) handleResourcesRead(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "Resource URI required",
+			},
+		}
+	}
+
+	store, rpcErr := s.storeFor(sess)
+	if rpcErr != nil {
+		return JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+
+	contents, ok := readResource(store, uri)
+	if !ok {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32002,
+				Message: "Resource not found",
+				Data:    map[string]interface{}{"uri": uri},
+			},
+		}
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"contents": contents,
+		},
+	}
+}
+
+// readResource returns the contents of the resource at uri, or false if
+// there is none
+func readResource(store storage.Store, uri string) ([]ResourceContents, bool) {
+	if uri == favoritesURI {
+		favorites := store.Favorites()
+		return []ResourceContents{
+			{URI: uri, MimeType: "application/json", Text: mustMarshal(getColorsResult{Count: len(favorites), Favorites: favorites})},
+			{URI: uri, MimeType: "text/markdown", Text: favoritesMarkdown(favorites)},
+		}, true
+	}
+
+	id := strings.TrimPrefix(uri, favoriteURIPrefix)
+	if id == uri || id == "" {
+		return nil, false
+	}
+	for _, fav := range store.Favorites() {
+		if fav.ID == id {
+			return []ResourceContents{{URI: uri, MimeType: "application/json", Text: mustMarshal(fav)}}, true
+		}
+	}
+	return nil, false
+}
+
+// favoritesMarkdown renders favorites as a Markdown list
+func favoritesMarkdown(favorites []storage.Favorite) string {
+	var b strings.Builder
+	b.WriteString("# Favorite colors\n\n")
+	if len(favorites) == 0 {
+		b.WriteString("You have no favorite colors yet.\n")
+		return b.String()
+	}
+	for _, fav := range favorites {
+		fmt.Fprintf(&b, "- **%s** `%s`", fav.Name, fav.Value)
+		if fav.Note != "" {
+			fmt.Fprintf(&b, " - %s", fav.Note)
+		}
+		if len(fav.Tags) > 0 {
+			fmt.Fprintf(&b, " (tags: %s)", strings.Join(fav.Tags, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
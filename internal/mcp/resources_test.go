@@ -0,0 +1,91 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServer_ResourcesList(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "Blue"})
+
+	response := request(t, server, sess, "resources/list", nil)
+	resources := response.Result.(map[string]interface{})["resources"].([]Resource)
+	if len(resources) != 2 || resources[0].URI != "colors://favorites" {
+		t.Fatalf("Expected the favorites list and Blue, got %+v", resources)
+	}
+	if !strings.HasPrefix(resources[1].URI, "colors://favorites/") || resources[1].Name != "Blue" {
+		t.Errorf("Expected Blue's resource, got %+v", resources[1])
+	}
+
+	response = request(t, server, sess, "resources/templates/list", nil)
+	templates := response.Result.(map[string]interface{})["resourceTemplates"].([]ResourceTemplate)
+	if len(templates) != 1 || templates[0].URITemplate != "colors://favorites/{id}" {
+		t.Errorf("Expected the favorite template, got %+v", templates)
+	}
+
+	response = request(t, server, sess, "initialize", map[string]interface{}{"protocolVersion": LatestProtocolVersion})
+	if capabilities := response.Result.(map[string]interface{})["capabilities"].(ServerCapabilities); capabilities.Resources == nil {
+		t.Error("Expected the resources capability")
+	}
+}
+
+func TestServer_ResourcesRead(t *testing.T) {
+	server := NewServer()
+	alice := &Session{Namespace: PrincipalNamespace("alice")}
+	callTool(t, server, alice, "add_color", map[string]interface{}{"color": "teal", "note": "calm", "tags": []interface{}{"cool"}})
+
+	response := request(t, server, alice, "resources/read", map[string]interface{}{"uri": "colors://favorites"})
+	contents := response.Result.(map[string]interface{})["contents"].([]ResourceContents)
+	if len(contents) != 2 || contents[0].MimeType != "application/json" || contents[1].MimeType != "text/markdown" {
+		t.Fatalf("Expected JSON and Markdown contents, got %+v", contents)
+	}
+	var list getColorsResult
+	if err := json.Unmarshal([]byte(contents[0].Text), &list); err != nil || list.Count != 1 {
+		t.Errorf("Expected the JSON list of one favorite, got %s (%v)", contents[0].Text, err)
+	}
+	if !strings.Contains(contents[1].Text, "- **teal** `#008080` - calm (tags: cool)") {
+		t.Errorf("Expected teal in the Markdown list, got %q", contents[1].Text)
+	}
+
+	uri := "colors://favorites/" + list.Favorites[0].ID
+	response = request(t, server, alice, "resources/read", map[string]interface{}{"uri": uri})
+	contents = response.Result.(map[string]interface{})["contents"].([]ResourceContents)
+	if len(contents) != 1 || contents[0].URI != uri || !strings.Contains(contents[0].Text, `"note":"calm"`) {
+		t.Errorf("Expected teal's record, got %+v", contents)
+	}
+
+	// Favorites of other namespaces do not exist for the session
+	bob := &Session{Namespace: PrincipalNamespace("bob")}
+	tests := []struct {
+		params map[string]interface{}
+		code   int
+	}{
+		{map[string]interface{}{"uri": uri}, -32002},
+		{map[string]interface{}{"uri": "colors://favorites/nope"}, -32002},
+		{map[string]interface{}{"uri": "colors://palettes"}, -32002},
+		{nil, -32602},
+	}
+	for _, tt := range tests {
+		response := request(t, server, bob, "resources/read", tt.params)
+		if response.Error == nil || response.Error.Code != tt.code {
+			t.Errorf("Expected error %d for %v, got %+v", tt.code, tt.params, response)
+		}
+	}
+}
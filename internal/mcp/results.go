@@ -0,0 +1,158 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "favorite-colors-mcp/internal/storage"
+
+// Structured results of the built-in tools. Each is returned as the
+// structuredContent of a tools/call result and described by the tool's
+// outputSchema.
+
+// addColorResult is the structured result of add_color
+type addColorResult struct {
+	Added    bool             `json:"added"`
+	Message  string           `json:"message"`
+	Favorite storage.Favorite `json:"favorite"`
+}
+
+// importColorsResult is the structured result of import_colors
+type importColorsResult struct {
+	Imported  int                `json:"imported"`
+	Unchanged int                `json:"unchanged"`
+	Message   string             `json:"message"`
+	Favorites []storage.Favorite `json:"favorites"`
+}
+
+// getColorsResult is the structured result of get_colors
+type getColorsResult struct {
+	Count     int                `json:"count"`
+	Favorites []storage.Favorite `json:"favorites"`
+}
+
+// removeColorResult is the structured result of remove_color
+type removeColorResult struct {
+	Removed bool   `json:"removed"`
+	Message string `json:"message"`
+}
+
+// clearColorsResult is the structured result of clear_colors
+type clearColorsResult struct {
+	Cleared int    `json:"cleared"`
+	Message string `json:"message"`
+}
+
+// listNamespacesResult is the structured result of list_namespaces
+type listNamespacesResult struct {
+	Namespaces []string `json:"namespaces"`
+}
+
+// setToolEnabledResult is the structured result of set_tool_enabled
+type setToolEnabledResult struct {
+	Tool    string `json:"tool"`
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// favoriteSchema describes a storage.Favorite
+var favoriteSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"id":        map[string]interface{}{"type": "string", "description": "Stable ID derived from the sRGB value"},
+		"value":     map[string]interface{}{"type": "string", "description": "Canonical sRGB hex, or lower-cased text for values that are not CSS colors"},
+		"name":      map[string]interface{}{"type": "string", "description": "The spelling the color was first added with"},
+		"note":      map[string]interface{}{"type": "string"},
+		"tags":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"createdAt": map[string]interface{}{"type": "string", "format": "date-time"},
+		"updatedAt": map[string]interface{}{"type": "string", "format": "date-time"},
+	},
+	"required":             []string{"id", "value", "name", "createdAt", "updatedAt"},
+	"additionalProperties": false,
+}
+
+// Output schemas of the built-in tools
+var (
+	addColorOutputSchema = &ToolSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"added":    map[string]interface{}{"type": "boolean", "description": "Whether the favorites changed"},
+			"message":  map[string]interface{}{"type": "string"},
+			"favorite": favoriteSchema,
+		},
+		Required:             []string{"added", "message", "favorite"},
+		AdditionalProperties: false,
+	}
+
+	importColorsOutputSchema = &ToolSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"imported":  map[string]interface{}{"type": "integer", "description": "Number of colors added or updated"},
+			"unchanged": map[string]interface{}{"type": "integer", "description": "Number of colors already favorites as given"},
+			"message":   map[string]interface{}{"type": "string"},
+			"favorites": map[string]interface{}{"type": "array", "items": favoriteSchema, "description": "The favorites added or updated"},
+		},
+		Required:             []string{"imported", "unchanged", "message", "favorites"},
+		AdditionalProperties: false,
+	}
+
+	getColorsOutputSchema = &ToolSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"count":     map[string]interface{}{"type": "integer"},
+			"favorites": map[string]interface{}{"type": "array", "items": favoriteSchema},
+		},
+		Required:             []string{"count", "favorites"},
+		AdditionalProperties: false,
+	}
+
+	removeColorOutputSchema = &ToolSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"removed": map[string]interface{}{"type": "boolean"},
+			"message": map[string]interface{}{"type": "string"},
+		},
+		Required:             []string{"removed", "message"},
+		AdditionalProperties: false,
+	}
+
+	clearColorsOutputSchema = &ToolSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"cleared": map[string]interface{}{"type": "integer", "description": "Number of colors removed"},
+			"message": map[string]interface{}{"type": "string"},
+		},
+		Required:             []string{"cleared", "message"},
+		AdditionalProperties: false,
+	}
+
+	listNamespacesOutputSchema = &ToolSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"namespaces": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		Required:             []string{"namespaces"},
+		AdditionalProperties: false,
+	}
+
+	setToolEnabledOutputSchema = &ToolSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"tool":    map[string]interface{}{"type": "string"},
+			"enabled": map[string]interface{}{"type": "boolean"},
+			"message": map[string]interface{}{"type": "string"},
+		},
+		Required:             []string{"tool", "enabled", "message"},
+		AdditionalProperties: false,
+	}
+)
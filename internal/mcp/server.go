@@ -15,20 +15,47 @@
 package mcp
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
 	"favorite-colors-mcp/internal/storage"
 )
 
+// maxImportColors bounds the colors of one import_colors call
+const maxImportColors = 1000
+
 // Server represents an MCP server instance
 type Server struct {
-	tools   map[string]Tool
-	storage *storage.ColorStorage
+	toolsMutex    sync.RWMutex
+	tools         map[string]registeredTool
+	disabledTools map[string]bool // by name, for every session
+	adminTools    map[string]bool // by name, disabled for sessions that are not Admin
+	backend       storage.Backend
+	ephemeral     storage.Backend // namespaces of Ephemeral sessions, never persisted
+
+	sessionsMutex sync.Mutex
+	sessions      map[*Session]struct{}
 }
 
-// NewServer creates a new MCP server
+// NewServer creates a new MCP server backed by in-memory storage
 func NewServer() *Server {
+	return NewServerWithStorage(storage.NewMemoryBackend())
+}
+
+// NewServerWithStorage creates a new MCP server backed by the given storage
+// backend. Each session operates on the backend namespace it belongs to;
+// those of Ephemeral sessions are kept in memory instead.
+func NewServerWithStorage(backend storage.Backend) *Server {
 	server := &Server{
-		tools:   make(map[string]Tool),
-		storage: storage.NewColorStorage(),
+		tools:         make(map[string]registeredTool),
+		disabledTools: make(map[string]bool),
+		adminTools:    make(map[string]bool),
+		backend:       backend,
+		ephemeral:     storage.NewMemoryBackend(),
+		sessions:      make(map[*Session]struct{}),
 	}
 	server.registerTools()
 	return server
@@ -38,26 +65,82 @@ func NewServer() *Server {
 func (s *Server) registerTools() {
 	s.RegisterTool(Tool{
 		Name:        "add_color",
-		Description: "Add a color to your favorites list",
+		Description: "Add a color to your favorites list, optionally with a note and tags. Adding a color that is already a favorite updates its note and tags.",
 		InputSchema: ToolSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
 				"color": map[string]interface{}{
 					"type":        "string",
-					"description": "The color to add to favorites",
+					"pattern":     `\S`,
+					"description": "The color to add to favorites: a CSS color name, hex code, or rgb(), hsl(), hwb(), lab(), lch(), oklab() or oklch() value",
+				},
+				"note": map[string]interface{}{
+					"type":        "string",
+					"description": "An optional note about the color. Adding a saved color again replaces its note; an empty note clears it.",
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Optional tags to group the color by. Adding a saved color again replaces its tags; an empty list clears them.",
 				},
 			},
-			Required: []string{"color"},
+			Required:             []string{"color"},
+			AdditionalProperties: false,
+		},
+		OutputSchema: addColorOutputSchema,
+		Annotations: &ToolAnnotations{
+			Title:           "Add favorite color",
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
 		},
-	})
+	}, s.handleAddColor)
+
+	s.RegisterTool(Tool{
+		Name:        "import_colors",
+		Description: "Add many colors to your favorites list at once, such as a palette, optionally tagging them all. Reports progress as colors are added.",
+		InputSchema: ToolSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"colors": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string", "pattern": `\S`},
+					"minItems":    1,
+					"maxItems":    maxImportColors,
+					"description": "The colors to add, in any format add_color accepts",
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Optional tags to give every imported color",
+				},
+			},
+			Required:             []string{"colors"},
+			AdditionalProperties: false,
+		},
+		OutputSchema: importColorsOutputSchema,
+		Annotations: &ToolAnnotations{
+			Title:           "Import favorite colors",
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, s.handleImportColors)
 
 	s.RegisterTool(Tool{
 		Name:        "get_colors",
 		Description: "Get all favorite colors",
 		InputSchema: ToolSchema{
-			Type: "object",
+			Type:                 "object",
+			AdditionalProperties: false,
+		},
+		OutputSchema: getColorsOutputSchema,
+		Annotations: &ToolAnnotations{
+			Title:         "Get favorite colors",
+			ReadOnlyHint:  boolPtr(true),
+			OpenWorldHint: boolPtr(false),
 		},
-	})
+	}, s.handleGetColors)
 
 	s.RegisterTool(Tool{
 		Name:        "remove_color",
@@ -67,36 +150,290 @@ func (s *Server) registerTools() {
 			Properties: map[string]interface{}{
 				"color": map[string]interface{}{
 					"type":        "string",
-					"description": "The color to remove from favorites",
+					"minLength":   1,
+					"description": "The color to remove from favorites, in any spelling of the same color",
 				},
 			},
-			Required: []string{"color"},
+			Required:             []string{"color"},
+			AdditionalProperties: false,
 		},
-	})
+		OutputSchema: removeColorOutputSchema,
+		Annotations: &ToolAnnotations{
+			Title:           "Remove favorite color",
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, s.handleRemoveColor)
 
 	s.RegisterTool(Tool{
 		Name:        "clear_colors",
 		Description: "Clear all favorite colors",
+		InputSchema: ToolSchema{
+			Type:                 "object",
+			AdditionalProperties: false,
+		},
+		OutputSchema: clearColorsOutputSchema,
+		Annotations: &ToolAnnotations{
+			Title:           "Clear favorite colors",
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, s.handleClearColors)
+
+	s.RegisterTool(Tool{
+		Name:        "list_namespaces",
+		Description: "List the namespaces that hold favorite colors (admin only)",
+		InputSchema: ToolSchema{
+			Type:                 "object",
+			AdditionalProperties: false,
+		},
+		OutputSchema: listNamespacesOutputSchema,
+		Annotations: &ToolAnnotations{
+			Title:         "List namespaces",
+			ReadOnlyHint:  boolPtr(true),
+			OpenWorldHint: boolPtr(false),
+		},
+		adminOnly: true,
+	}, s.handleListNamespaces)
+
+	s.RegisterTool(Tool{
+		Name:        "set_tool_enabled",
+		Description: "Enable or disable a tool for every session (admin only)",
 		InputSchema: ToolSchema{
 			Type: "object",
+			Properties: map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"minLength":   1,
+					"description": "The name of the tool",
+				},
+				"enabled": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Whether sessions may list and call the tool",
+				},
+			},
+			Required:             []string{"name", "enabled"},
+			AdditionalProperties: false,
+		},
+		OutputSchema: setToolEnabledOutputSchema,
+		Annotations: &ToolAnnotations{
+			Title:          "Enable or disable a tool",
+			IdempotentHint: boolPtr(true),
+			OpenWorldHint:  boolPtr(false),
 		},
-	})
+		adminOnly: true,
+	}, s.handleSetToolEnabled)
+}
+
+// RegisterTool registers a tool and the handler that executes its calls,
+// replacing any tool registered under the same name. Arguments are checked
+// against the tool's InputSchema before the handler runs. Initialized
+// sessions are sent notifications/tools/list_changed. It panics if handler
+// is nil or the InputSchema is invalid, such as with a pattern that does not
+// compile.
+func (s *Server) RegisterTool(tool Tool, handler ToolHandler) {
+	if handler == nil {
+		panic("mcp: RegisterTool handler is nil for tool " + tool.Name)
+	}
+
+	inputSchema, err := compileSchema(tool.InputSchema)
+	if err != nil {
+		panic(fmt.Sprintf("mcp: invalid input schema for tool %s: %v", tool.Name, err))
+	}
+
+	s.toolsMutex.Lock()
+	s.tools[tool.Name] = registeredTool{tool: tool, handler: handler, inputSchema: inputSchema}
+	s.toolsMutex.Unlock()
+
+	s.toolsListChanged(s.Sessions())
+}
+
+// lookupTool returns the registered tool called name, if the session may
+// see it. Admin-only tools are hidden from other sessions, and disabled
+// tools from the sessions they are disabled for, so they do not exist as far
+// as those sessions are concerned.
+func (s *Server) lookupTool(sess *Session, name string) (registeredTool, bool) {
+	s.toolsMutex.RLock()
+	defer s.toolsMutex.RUnlock()
+
+	registered, ok := s.tools[name]
+	if !ok || !s.toolVisible(sess, registered) {
+		return registeredTool{}, false
+	}
+	return registered, true
+}
+
+// OpenSession registers a session for a new connection, which must then be
+// initialized before it is used. Tools restricted to admins are disabled
+// for it unless it is Admin. It returns sess.
+func (s *Server) OpenSession(sess *Session) *Session {
+	sess.mutex.Lock()
+	sess.state = stateNew
+	sess.mutex.Unlock()
+	s.disableAdminTools(sess)
+
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+	s.sessions[sess] = struct{}{}
+	return sess
+}
+
+// CloseSession ends a session opened with OpenSession, such as when its
+// connection closes. Its requests in flight are cancelled, later requests
+// on it fail, and the namespace of an ephemeral session is dropped. Closing
+// a session again is a no-op.
+func (s *Server) CloseSession(sess *Session) error {
+	s.sessionsMutex.Lock()
+	_, open := s.sessions[sess]
+	delete(s.sessions, sess)
+	s.sessionsMutex.Unlock()
+
+	if !open {
+		return nil
+	}
+
+	sess.mutex.Lock()
+	sess.state = stateClosed
+	sess.mutex.Unlock()
+	sess.cancelAll()
+
+	if sess.Ephemeral {
+		if err := s.ephemeral.Drop(sess.Namespace); err != nil {
+			return fmt.Errorf("error dropping namespace %q: %w", sess.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// Sessions returns the sessions currently open
+func (s *Server) Sessions() []*Session {
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+
+	sessions := make([]*Session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
 }
 
-// RegisterTool registers a new tool with the server
-func (s *Server) RegisterTool(tool Tool) {
-	s.tools[tool.Name] = tool
+// HandleRequest processes an MCP request from an anonymous client using the
+// default namespace and returns its response, or nil for a notification. The
+// request stands on its own, outside of any session lifecycle.
+func (s *Server) HandleRequest(ctx context.Context, req JSONRPCRequest) *JSONRPCResponse {
+	return s.HandleSessionRequest(ctx, &Session{}, req)
 }
 
-// HandleRequest processes an MCP request and returns a response
-func (s *Server) HandleRequest(req JSONRPCRequest) JSONRPCResponse {
+// HandleSessionRequest processes an MCP request on behalf of a session and
+// returns its response. Notifications are processed the same way but never
+// answered, so the response is nil.
+//
+// The request is handled with ctx, which transports cancel when the client
+// can no longer receive the response, and which notifications/cancelled
+// naming the request's ID cancels too. Cancelled requests are not answered
+// either.
+func (s *Server) HandleSessionRequest(ctx context.Context, sess *Session, req JSONRPCRequest) *JSONRPCResponse {
+	return s.startSessionRequest(ctx, sess, req)()
+}
+
+// startSessionRequest registers a request as in flight on the session and
+// returns the function that handles it, which must be called
+func (s *Server) startSessionRequest(ctx context.Context, sess *Session, req JSONRPCRequest) func() *JSONRPCResponse {
+	if req.IsNotification() {
+		return func() *JSONRPCResponse {
+			// There is no one to tell of a refused notification but the logs
+			if response := s.dispatch(ctx, sess, req); response.Error != nil {
+				s.logEvent(ctx, sess, LogWarning, "mcp", "Notification refused", map[string]interface{}{
+					"method": req.Method,
+					"error":  response.Error.Message,
+				})
+			}
+			return nil
+		}
+	}
+
+	ctx, done := sess.track(ctx, req.ID)
+	return func() *JSONRPCResponse {
+		defer done()
+		return s.handleTracked(ctx, sess, req)
+	}
+}
+
+// handleTracked handles a request registered as in flight with ctx
+func (s *Server) handleTracked(ctx context.Context, sess *Session, req JSONRPCRequest) *JSONRPCResponse {
+	s.logEvent(ctx, sess, LogDebug, "mcp", "Handling request", map[string]interface{}{"method": req.Method, "id": req.ID})
+	response := s.dispatch(ctx, sess, req)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if response.Error != nil {
+		// Internal errors are the server's to fix; others the client's
+		level := LogDebug
+		if response.Error.Code == -32603 {
+			level = LogError
+		}
+		s.logEvent(ctx, sess, level, "mcp", "Request failed", map[string]interface{}{
+			"method": req.Method,
+			"id":     req.ID,
+			"code":   response.Error.Code,
+			"error":  response.Error.Message,
+		})
+	}
+	return &response
+}
+
+// dispatch runs the handler of a request's method
+func (s *Server) dispatch(ctx context.Context, sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	if err := sess.admit(req.Method); err != nil {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   err,
+		}
+	}
+
 	switch req.Method {
 	case "initialize":
-		return s.handleInitialize(req)
+		return s.handleInitialize(sess, req)
+	case "notifications/initialized":
+		sess.transition(stateInitializing, stateReady)
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  map[string]interface{}{},
+		}
+	case "notifications/cancelled":
+		return s.handleCancelled(sess, req)
+	case "ping":
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  map[string]interface{}{},
+		}
 	case "tools/list":
-		return s.handleToolsList(req)
+		return s.handleToolsList(sess, req)
 	case "tools/call":
-		return s.handleToolsCall(req)
+		return s.handleToolsCall(ctx, sess, req)
+	case "resources/list":
+		return s.handleResourcesList(sess, req)
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList(req)
+	case "resources/read":
+		return s.handleResourcesRead(sess, req)
+	case "prompts/list":
+		return s.handlePromptsList(req)
+	case "prompts/get":
+		return s.handlePromptsGet(sess, req)
+	case "completion/complete":
+		return s.handleComplete(sess, req)
+	case "logging/setLevel":
+		return s.handleSetLevel(sess, req)
+	case "resources/subscribe":
+		return s.handleResourcesSubscribe(sess, req, true)
+	case "resources/unsubscribe":
+		return s.handleResourcesSubscribe(sess, req, false)
 	default:
 		return JSONRPCResponse{
 			JSONRPC: "2.0",
@@ -109,30 +446,70 @@ func (s *Server) HandleRequest(req JSONRPCRequest) JSONRPCResponse {
 	}
 }
 
-// handleInitialize handles the initialize method
-func (s *Server) handleInitialize(req JSONRPCRequest) JSONRPCResponse {
+// handleInitialize handles the initialize method. It negotiates the
+// protocol version and records the client's capabilities on the session.
+func (s *Server) handleInitialize(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	requested, _ := params["protocolVersion"].(string)
+	capabilities, _ := params["capabilities"].(map[string]interface{})
+
+	var info ClientInfo
+	if clientInfo, ok := params["clientInfo"].(map[string]interface{}); ok {
+		info.Name, _ = clientInfo["name"].(string)
+		info.Version, _ = clientInfo["version"].(string)
+	}
+
+	if !sess.beginInitialize() {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32600,
+				Message: "Session already initialized",
+			},
+		}
+	}
+
+	version := negotiateProtocolVersion(requested)
+	sess.negotiated(version, capabilities, info)
+
+	serverCapabilities := ServerCapabilities{
+		Tools:     ToolsCapability{ListChanged: true},
+		Resources: &ResourcesCapability{Subscribe: true},
+		Prompts:   &PromptsCapability{},
+	}
+	if sess.Supports(FeatureCompletions) {
+		serverCapabilities.Completions = &CompletionsCapability{}
+	}
+	if sess.Supports(FeatureLogging) {
+		serverCapabilities.Logging = &LoggingCapability{}
+	}
+
 	return JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
 		Result: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
+			"protocolVersion": version,
 			"serverInfo": ServerInfo{
 				Name:    "favorite-colors-mcp",
 				Version: "1.0.0",
 			},
-			"capabilities": ServerCapabilities{
-				Tools: struct{}{},
-			},
+			"capabilities": serverCapabilities,
 		},
 	}
 }
 
 // handleToolsList handles the tools/list method
-func (s *Server) handleToolsList(req JSONRPCRequest) JSONRPCResponse {
+func (s *Server) handleToolsList(sess *Session, req JSONRPCRequest) JSONRPCResponse {
+	s.toolsMutex.RLock()
 	tools := make([]Tool, 0, len(s.tools))
-	for _, tool := range s.tools {
-		tools = append(tools, tool)
+	for _, registered := range s.tools {
+		if !s.toolVisible(sess, registered) {
+			continue
+		}
+		tools = append(tools, toolForSession(sess, registered.tool))
 	}
+	s.toolsMutex.RUnlock()
 
 	return JSONRPCResponse{
 		JSONRPC: "2.0",
@@ -143,8 +520,20 @@ func (s *Server) handleToolsList(req JSONRPCRequest) JSONRPCResponse {
 	}
 }
 
+// toolForSession strips the fields of a tool definition that the session's
+// protocol version does not know about
+func toolForSession(sess *Session, tool Tool) Tool {
+	if !sess.Supports(FeatureStructuredContent) {
+		tool.OutputSchema = nil
+	}
+	if !sess.Supports(FeatureToolAnnotations) {
+		tool.Annotations = nil
+	}
+	return tool
+}
+
 // handleToolsCall handles the tools/call method
-func (s *Server) handleToolsCall(req JSONRPCRequest) JSONRPCResponse {
+func (s *Server) handleToolsCall(ctx context.Context, sess *Session, req JSONRPCRequest) JSONRPCResponse {
 	params, ok := req.Params.(map[string]interface{})
 	if !ok {
 		return JSONRPCResponse{
@@ -169,18 +558,8 @@ func (s *Server) handleToolsCall(req JSONRPCRequest) JSONRPCResponse {
 		}
 	}
 
-	arguments, _ := params["arguments"].(map[string]interface{})
-
-	switch toolName {
-	case "add_color":
-		return s.handleAddColor(req, arguments)
-	case "get_colors":
-		return s.handleGetColors(req, arguments)
-	case "remove_color":
-		return s.handleRemoveColor(req, arguments)
-	case "clear_colors":
-		return s.handleClearColors(req, arguments)
-	default:
+	registered, ok := s.lookupTool(sess, toolName)
+	if !ok {
 		return JSONRPCResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
@@ -190,103 +569,287 @@ func (s *Server) handleToolsCall(req JSONRPCRequest) JSONRPCResponse {
 			},
 		}
 	}
-}
 
-// handleAddColor handles the add_color tool
-func (s *Server) handleAddColor(req JSONRPCRequest, args map[string]interface{}) JSONRPCResponse {
-	color, ok := args["color"].(string)
-	if !ok || color == "" {
+	// Missing arguments are an empty object, which the schema may reject
+	rawArguments, ok := params["arguments"]
+	if !ok || rawArguments == nil {
+		rawArguments = map[string]interface{}{}
+	}
+	if violations := registered.inputSchema.validate(rawArguments); len(violations) > 0 {
 		return JSONRPCResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Error: &JSONRPCError{
 				Code:    -32602,
-				Message: "Color parameter required",
+				Message: "Invalid params",
+				Data:    violations,
 			},
 		}
 	}
+	arguments, _ := normalizeJSON(rawArguments).(map[string]interface{})
 
-	message, added := s.storage.AddColor(color)
-	_ = added // We don't need the boolean for MCP response
+	result, err := registered.handler(withCall(ctx, s, sess, progressToken(params)), arguments)
+	if err != nil {
+		var rpcErr *JSONRPCError
+		if errors.As(err, &rpcErr) {
+			return JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   rpcErr,
+			}
+		}
+		result = &ToolResult{Text: err.Error(), IsError: true}
+	}
+	if result == nil {
+		result = &ToolResult{}
+	}
+
+	response := map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": result.Text,
+			},
+		},
+	}
+	if result.Structured != nil && sess.Supports(FeatureStructuredContent) {
+		response["structuredContent"] = result.Structured
+	}
+	if result.IsError {
+		response["isError"] = true
+	}
 
 	return JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result: map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": message,
-				},
-			},
+		Result:  response,
+	}
+}
+
+// handleAddColor handles the add_color tool
+func (s *Server) handleAddColor(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+	store, err := StoreFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The input schema guarantees the types of the arguments. A note or tags
+	// left out are kept as they are, while empty ones clear them.
+	color, _ := args["color"].(string)
+	var note *string
+	if value, ok := args["note"].(string); ok {
+		note = &value
+	}
+	tags := optionalStringList(args, "tags")
+
+	fav, message, added, err := store.AddFavorite(color, note, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ToolResult{
+		Text: message,
+		Structured: addColorResult{
+			Added:    added,
+			Message:  message,
+			Favorite: fav,
 		},
+	}, nil
+}
+
+// handleImportColors adds a list of colors, reporting progress after each.
+// The import is logged, and notified to subscribers, as a single change.
+func (s *Server) handleImportColors(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+	store, err := storeFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
+
+	colors, _ := stringList(args["colors"])
+	tags := optionalStringList(args, "tags")
+
+	result := importColorsResult{Favorites: []storage.Favorite{}}
+	var ids []string
+	defer func() {
+		if len(ids) > 0 {
+			store.changed("Imported colors", ids)
+		}
+	}()
+	for i, color := range colors {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fav, message, added, err := store.Store.AddFavorite(color, nil, tags)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			result.Imported++
+			result.Favorites = append(result.Favorites, fav)
+			ids = append(ids, fav.ID)
+		} else {
+			result.Unchanged++
+		}
+		// A lost progress notification does not fail the import
+		_ = ReportProgress(ctx, float64(i+1), float64(len(colors)), message)
+	}
+
+	result.Message = fmt.Sprintf("Imported %d of %d colors into your favorites (%d unchanged)", result.Imported, len(colors), result.Unchanged)
+	return &ToolResult{Text: result.Message, Structured: result}, nil
 }
 
 // handleGetColors handles the get_colors tool
-func (s *Server) handleGetColors(req JSONRPCRequest, _ map[string]interface{}) JSONRPCResponse {
-	_, text := s.storage.GetColors()
+func (s *Server) handleGetColors(ctx context.Context, _ map[string]interface{}) (*ToolResult, error) {
+	store, err := StoreFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	return JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result: map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": text,
-				},
-			},
+	_, text := store.GetColors()
+	favorites := store.Favorites()
+
+	return &ToolResult{
+		Text: text,
+		Structured: getColorsResult{
+			Count:     len(favorites),
+			Favorites: favorites,
 		},
-	}
+	}, nil
 }
 
 // handleRemoveColor handles the remove_color tool
-func (s *Server) handleRemoveColor(req JSONRPCRequest, args map[string]interface{}) JSONRPCResponse {
-	color, ok := args["color"].(string)
-	if !ok || color == "" {
-		return JSONRPCResponse{
-			JSONRPC: "2.0",
-			ID:      req.ID,
-			Error: &JSONRPCError{
-				Code:    -32602,
-				Message: "Color parameter required",
-			},
-		}
+func (s *Server) handleRemoveColor(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+	store, err := StoreFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	message, removed := s.storage.RemoveColor(color)
-	_ = removed // We don't need the boolean for MCP response
+	color, _ := args["color"].(string)
 
-	return JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result: map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": message,
-				},
-			},
-		},
+	_, message, removed, err := store.RemoveColor(color)
+	if err != nil {
+		return nil, err
 	}
+
+	return &ToolResult{
+		Text: message,
+		Structured: removeColorResult{
+			Removed: removed,
+			Message: message,
+		},
+	}, nil
 }
 
 // handleClearColors handles the clear_colors tool
-func (s *Server) handleClearColors(req JSONRPCRequest, _ map[string]interface{}) JSONRPCResponse {
-	message, count := s.storage.ClearColors()
-	_ = count // We don't need the count for MCP response
+func (s *Server) handleClearColors(ctx context.Context, _ map[string]interface{}) (*ToolResult, error) {
+	store, err := StoreFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	return JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result: map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": message,
-				},
-			},
+	ids, message, err := store.ClearColors()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ToolResult{
+		Text: message,
+		Structured: clearColorsResult{
+			Cleared: len(ids),
+			Message: message,
+		},
+	}, nil
+}
+
+// handleSetToolEnabled handles the set_tool_enabled tool
+func (s *Server) handleSetToolEnabled(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+	name, _ := args["name"].(string)
+	enabled, _ := args["enabled"].(bool)
+
+	// Disabled, it could not be enabled again until a restart
+	if name == "set_tool_enabled" && !enabled {
+		return nil, fmt.Errorf("set_tool_enabled cannot be disabled")
+	}
+	if err := s.SetToolEnabled(name, enabled); err != nil {
+		return nil, err
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	message := fmt.Sprintf("Tool '%s' is %s for every session", name, state)
+	s.logEvent(ctx, SessionFromContext(ctx), LogNotice, "tools", "Tool "+state, map[string]interface{}{"tool": name})
+	return &ToolResult{
+		Text: message,
+		Structured: setToolEnabledResult{
+			Tool:    name,
+			Enabled: enabled,
+			Message: message,
 		},
+	}, nil
+}
+
+// handleListNamespaces handles the list_namespaces tool
+func (s *Server) handleListNamespaces(ctx context.Context, _ map[string]interface{}) (*ToolResult, error) {
+	namespaces, err := s.backend.Namespaces()
+	if err != nil {
+		s.logEvent(ctx, SessionFromContext(ctx), LogError, "storage", "Error listing namespaces", map[string]interface{}{"error": err.Error()})
+		return nil, &JSONRPCError{
+			Code:    -32603,
+			Message: "Storage unavailable",
+		}
 	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Namespaces (%d total):\n", len(namespaces))
+	for i, namespace := range namespaces {
+		if namespace == "" {
+			namespace = "(default)"
+		}
+		fmt.Fprintf(&text, "%d. %s\n", i+1, namespace)
+	}
+
+	return &ToolResult{
+		Text: text.String(),
+		Structured: listNamespacesResult{
+			Namespaces: namespaces,
+		},
+	}, nil
+}
+
+// stringList converts a JSON array of strings decoded into an interface{}.
+// A missing value is an empty list.
+func stringList(value interface{}) ([]string, bool) {
+	if value == nil {
+		return nil, true
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		list = append(list, s)
+	}
+	return list, true
+}
+
+// optionalStringList returns the string list argument name, or nil if it
+// was left out
+func optionalStringList(args map[string]interface{}, name string) *[]string {
+	if _, ok := args[name]; !ok {
+		return nil
+	}
+	list, _ := stringList(args[name])
+	return &list
+}
+
+func boolPtr(b bool) *bool {
+	return &b
 }
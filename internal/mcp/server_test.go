@@ -1,8 +1,27 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
 package mcp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
+
+	"favorite-colors-mcp/internal/storage"
 )
 
 func TestServer_Initialize(t *testing.T) {
@@ -21,7 +40,7 @@ func TestServer_Initialize(t *testing.T) {
 		},
 	}
 
-	response := server.HandleRequest(req)
+	response := server.HandleRequest(context.Background(), req)
 
 	if response.Error != nil {
 		t.Fatalf("Expected no error, got: %v", response.Error)
@@ -56,7 +75,7 @@ func TestServer_ToolsList(t *testing.T) {
 		Params:  map[string]interface{}{},
 	}
 
-	response := server.HandleRequest(req)
+	response := server.HandleRequest(context.Background(), req)
 
 	if response.Error != nil {
 		t.Fatalf("Expected no error, got: %v", response.Error)
@@ -72,16 +91,17 @@ func TestServer_ToolsList(t *testing.T) {
 		t.Fatal("Expected tools to be a slice of Tool")
 	}
 
-	if len(tools) != 4 {
-		t.Errorf("Expected 4 tools, got %d", len(tools))
+	if len(tools) != 5 {
+		t.Errorf("Expected 5 tools, got %d", len(tools))
 	}
 
 	// Check that all expected tools are present
 	expectedTools := map[string]bool{
-		"add_color":    false,
-		"get_colors":   false,
-		"remove_color": false,
-		"clear_colors": false,
+		"add_color":     false,
+		"import_colors": false,
+		"get_colors":    false,
+		"remove_color":  false,
+		"clear_colors":  false,
 	}
 
 	for _, tool := range tools {
@@ -112,7 +132,7 @@ func TestServer_ToolsCall_AddColor(t *testing.T) {
 		},
 	}
 
-	response := server.HandleRequest(req)
+	response := server.HandleRequest(context.Background(), req)
 
 	if response.Error != nil {
 		t.Fatalf("Expected no error, got: %v", response.Error)
@@ -155,7 +175,7 @@ func TestServer_ToolsCall_InvalidTool(t *testing.T) {
 		},
 	}
 
-	response := server.HandleRequest(req)
+	response := server.HandleRequest(context.Background(), req)
 
 	if response.Error == nil {
 		t.Fatal("Expected error for invalid tool")
@@ -183,13 +203,107 @@ func TestServer_ToolsCall_MissingParams(t *testing.T) {
 		},
 	}
 
-	response := server.HandleRequest(req)
+	response := server.HandleRequest(context.Background(), req)
 
 	if response.Error == nil {
 		t.Fatal("Expected error for missing tool name")
 	}
 }
 
+func TestServer_ToolsCall_AddColorNoteAndTags(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+
+	callTool(t, server, sess, "add_color", map[string]interface{}{
+		"color": "teal",
+		"note":  "accent",
+		"tags":  []interface{}{"cool", "ui"},
+	})
+	if text := resultText(t, callTool(t, server, sess, "get_colors", nil)); !strings.Contains(text, "teal - accent [cool, ui]") {
+		t.Errorf("Expected note and tags in get_colors, got: %s", text)
+	}
+
+	// Left out, the tags are kept; empty, the note is cleared
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "teal", "note": ""})
+	if text := resultText(t, callTool(t, server, sess, "get_colors", nil)); !strings.Contains(text, "1. teal [cool, ui]") {
+		t.Errorf("Expected the note cleared and the tags kept, got: %s", text)
+	}
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "teal", "tags": []interface{}{}})
+	if text := resultText(t, callTool(t, server, sess, "get_colors", nil)); !strings.Contains(text, "1. teal\n") {
+		t.Errorf("Expected the tags cleared, got: %s", text)
+	}
+
+	tests := []map[string]interface{}{
+		{"color": "   "},
+		{"color": "red", "note": 42},
+		{"color": "red", "tags": "cool"},
+		{"color": "red", "tags": []interface{}{"cool", 1}},
+	}
+	for _, args := range tests {
+		response := callTool(t, server, sess, "add_color", args)
+		if response.Error == nil || response.Error.Code != -32602 {
+			t.Errorf("Expected invalid params error for %v, got %+v", args, response)
+		}
+	}
+	response := callTool(t, server, sess, "import_colors", map[string]interface{}{"colors": []interface{}{"red", " "}})
+	if response.Error == nil || response.Error.Code != -32602 {
+		t.Errorf("Expected invalid params error for a blank imported color, got %+v", response)
+	}
+}
+
+// failingStore is a store whose mutations cannot be saved
+type failingStore struct {
+	storage.Store
+}
+
+func (failingStore) AddFavorite(string, *string, *[]string) (storage.Favorite, string, bool, error) {
+	return storage.Favorite{}, "", false, errors.New("disk full")
+}
+
+func TestServer_ToolsCall_StorageFailure(t *testing.T) {
+	server := NewServerWithStorage(storage.NewBackend(func(string) (storage.Store, error) {
+		return failingStore{storage.NewColorStorage()}, nil
+	}))
+
+	response := callTool(t, server, &Session{}, "add_color", map[string]interface{}{"color": "red"})
+	result := response.Result.(map[string]interface{})
+	if result["isError"] != true || !strings.Contains(resultText(t, response), "disk full") {
+		t.Errorf("Expected the storage error reported as a tool error, got %v", result)
+	}
+}
+
+func TestServer_ToolsCall_InvalidArguments(t *testing.T) {
+	server := NewServer()
+
+	response := callTool(t, server, &Session{}, "add_color", map[string]interface{}{
+		"color":  "",
+		"tags":   []interface{}{"cool", 7},
+		"shade":  "dark",
+		"note":   true,
+		"nested": map[string]interface{}{},
+	})
+	if response.Error == nil || response.Error.Code != -32602 {
+		t.Fatalf("Expected invalid params error, got %+v", response)
+	}
+
+	violations, ok := response.Error.Data.([]SchemaViolation)
+	if !ok {
+		t.Fatalf("Expected violations in error data, got %T", response.Error.Data)
+	}
+	got := make([]string, len(violations))
+	for i, v := range violations {
+		got[i] = v.Pointer
+	}
+	if want := "/color,/nested,/note,/shade,/tags/1"; strings.Join(got, ",") != want {
+		t.Errorf("Expected violations at %s, got %v", want, violations)
+	}
+
+	// Nothing was stored
+	if text := resultText(t, callTool(t, server, &Session{}, "get_colors", nil)); !strings.Contains(text, "no favorite colors") {
+		t.Errorf("Expected no colors after rejected call, got: %s", text)
+	}
+}
+
 func TestServer_InvalidMethod(t *testing.T) {
 	server := NewServer()
 
@@ -200,7 +314,7 @@ func TestServer_InvalidMethod(t *testing.T) {
 		Params:  map[string]interface{}{},
 	}
 
-	response := server.HandleRequest(req)
+	response := server.HandleRequest(context.Background(), req)
 
 	if response.Error == nil {
 		t.Fatal("Expected error for invalid method")
@@ -215,6 +329,185 @@ func TestServer_InvalidMethod(t *testing.T) {
 	}
 }
 
+// request sends a request on behalf of sess and returns the response
+func request(t *testing.T, server *Server, sess *Session, method string, params map[string]interface{}) *JSONRPCResponse {
+	t.Helper()
+	response := server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  method,
+		Params:  params,
+	})
+	if response == nil {
+		t.Fatalf("Expected a response to %s", method)
+	}
+	return response
+}
+
+// callTool calls a tool on behalf of sess
+func callTool(t *testing.T, server *Server, sess *Session, name string, args map[string]interface{}) *JSONRPCResponse {
+	t.Helper()
+	return request(t, server, sess, "tools/call", map[string]interface{}{
+		"name":      name,
+		"arguments": args,
+	})
+}
+
+func resultText(t *testing.T, response *JSONRPCResponse) string {
+	t.Helper()
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got: %v", response.Error)
+	}
+	result := response.Result.(map[string]interface{})
+	content := result["content"].([]map[string]interface{})
+	return content[0]["text"].(string)
+}
+
+func TestServer_SessionNamespaces(t *testing.T) {
+	server := NewServer()
+	alice := &Session{Namespace: PrincipalNamespace("alice")}
+	bob := &Session{Namespace: SessionNamespace("b0b")}
+
+	callTool(t, server, alice, "add_color", map[string]interface{}{"color": "blue"})
+	callTool(t, server, bob, "add_color", map[string]interface{}{"color": "green"})
+
+	if text := resultText(t, callTool(t, server, alice, "get_colors", nil)); !strings.Contains(text, "blue") || strings.Contains(text, "green") {
+		t.Errorf("Expected alice to see only blue, got: %s", text)
+	}
+
+	callTool(t, server, bob, "clear_colors", nil)
+	if text := resultText(t, callTool(t, server, alice, "get_colors", nil)); !strings.Contains(text, "1 total") {
+		t.Errorf("Expected bob's clear to leave alice untouched, got: %s", text)
+	}
+
+	text := resultText(t, callTool(t, server, alice, "remove_color", map[string]interface{}{"color": "green"}))
+	if !strings.Contains(text, "was not found") {
+		t.Errorf("Expected alice not to remove bob's color, got: %s", text)
+	}
+}
+
+func TestServer_ListNamespacesAdminOnly(t *testing.T) {
+	server := NewServer()
+	user := &Session{Namespace: PrincipalNamespace("alice")}
+	admin := &Session{Namespace: PrincipalNamespace("root"), Admin: true}
+
+	callTool(t, server, user, "add_color", map[string]interface{}{"color": "blue"})
+
+	response := callTool(t, server, user, "list_namespaces", nil)
+	if response.Error == nil || response.Error.Code != -32601 {
+		t.Fatalf("Expected non-admin list_namespaces to be rejected, got: %+v", response)
+	}
+
+	listed := func(sess *Session) bool {
+		response := server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"})
+		for _, tool := range response.Result.(map[string]interface{})["tools"].([]Tool) {
+			if tool.Name == "list_namespaces" {
+				return true
+			}
+		}
+		return false
+	}
+	if listed(user) {
+		t.Error("Expected list_namespaces to be hidden from non-admins")
+	}
+	if !listed(admin) {
+		t.Error("Expected list_namespaces to be listed for admins")
+	}
+
+	text := resultText(t, callTool(t, server, admin, "list_namespaces", nil))
+	if !strings.Contains(text, "user:alice") {
+		t.Errorf("Expected user:alice in namespaces, got: %s", text)
+	}
+}
+
+func TestServer_StructuredContent(t *testing.T) {
+	server := NewServer()
+	admin := &Session{Admin: true}
+
+	calls := []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"add_color", map[string]interface{}{"color": "teal", "note": "accent", "tags": []interface{}{"cool"}}},
+		{"add_color", map[string]interface{}{"color": "teal"}},
+		{"add_color", map[string]interface{}{"color": "red"}},
+		{"import_colors", map[string]interface{}{"colors": []interface{}{"red", "#00f"}, "tags": []interface{}{"palette"}}},
+		{"get_colors", nil},
+		{"remove_color", map[string]interface{}{"color": "red"}},
+		{"remove_color", map[string]interface{}{"color": "red"}},
+		{"list_namespaces", nil},
+		{"clear_colors", nil},
+		{"get_colors", nil},
+	}
+
+	for _, call := range calls {
+		schema := server.tools[call.tool].tool.OutputSchema
+		if schema == nil {
+			t.Fatalf("Expected %s to declare an output schema", call.tool)
+		}
+
+		response := callTool(t, server, admin, call.tool, call.args)
+		resultText(t, response)
+		structured := roundTrip(t, response.Result.(map[string]interface{})["structuredContent"])
+		compiled, err := compileSchema(schema)
+		if err != nil {
+			t.Fatalf("Invalid output schema for %s: %v", call.tool, err)
+		}
+		for _, violation := range compiled.validate(structured) {
+			t.Errorf("%s %v: %s: %s", call.tool, call.args, violation.Pointer, violation.Message)
+		}
+	}
+
+	response := callTool(t, server, admin, "get_colors", nil)
+	result := response.Result.(map[string]interface{})["structuredContent"].(getColorsResult)
+	if result.Count != 0 || len(result.Favorites) != 0 {
+		t.Errorf("Expected no favorites after clear_colors, got %+v", result)
+	}
+}
+
+func TestServer_StructuredContentRoundTripsFavorites(t *testing.T) {
+	server := NewServer()
+	sess := &Session{}
+
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "#00F", "note": "sky", "tags": []interface{}{"cool", "ui"}})
+	response := callTool(t, server, sess, "get_colors", nil)
+
+	var result struct {
+		Count     int `json:"count"`
+		Favorites []struct {
+			Name  string   `json:"name"`
+			Value string   `json:"value"`
+			Note  string   `json:"note"`
+			Tags  []string `json:"tags"`
+		} `json:"favorites"`
+	}
+	data, _ := json.Marshal(response.Result.(map[string]interface{})["structuredContent"])
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to decode structured content: %v", err)
+	}
+	if result.Count != 1 || len(result.Favorites) != 1 {
+		t.Fatalf("Expected 1 favorite, got %s", data)
+	}
+	fav := result.Favorites[0]
+	if fav.Name != "#00F" || fav.Value != "#0000ff" || fav.Note != "sky" || strings.Join(fav.Tags, ",") != "cool,ui" {
+		t.Errorf("Unexpected favorite %s", data)
+	}
+}
+
+// roundTrip converts v to its generic JSON form, as a client would see it
+func roundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal %v: %v", v, err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", data, err)
+	}
+	return out
+}
+
 func BenchmarkServer_HandleRequest(b *testing.B) {
 	server := NewServer()
 
@@ -233,6 +526,193 @@ func BenchmarkServer_HandleRequest(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		server.HandleRequest(req)
+		server.HandleRequest(context.Background(), req)
+	}
+}
+
+func initializeSession(t *testing.T, server *Server, sess *Session, version string, capabilities map[string]interface{}) string {
+	t.Helper()
+	response := server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "initialize",
+		Params: map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    capabilities,
+			"clientInfo":      map[string]interface{}{"name": "test", "version": "1.0.0"},
+		},
+	})
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got: %v", response.Error)
+	}
+	return response.Result.(map[string]interface{})["protocolVersion"].(string)
+}
+
+func TestServer_InitializeNegotiation(t *testing.T) {
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{ProtocolVersion20241105, ProtocolVersion20241105},
+		{ProtocolVersion20250326, ProtocolVersion20250326},
+		{ProtocolVersion20250618, ProtocolVersion20250618},
+		{"2099-01-01", LatestProtocolVersion},
+		{"", LatestProtocolVersion},
+	}
+
+	for _, tt := range tests {
+		sess := &Session{}
+		if got := initializeSession(t, NewServer(), sess, tt.requested, nil); got != tt.want {
+			t.Errorf("Requested %q: expected %s, got %s", tt.requested, tt.want, got)
+		}
+		if sess.ProtocolVersion() != tt.want {
+			t.Errorf("Requested %q: expected session version %s, got %s", tt.requested, tt.want, sess.ProtocolVersion())
+		}
+	}
+
+	sess := &Session{}
+	initializeSession(t, NewServer(), sess, ProtocolVersion20250618, map[string]interface{}{"elicitation": map[string]interface{}{}})
+	if _, ok := sess.ClientCapabilities()["elicitation"]; !ok {
+		t.Errorf("Expected client capabilities to be stored, got %v", sess.ClientCapabilities())
+	}
+	if sess.ClientInfo().Name != "test" {
+		t.Errorf("Expected client info to be stored, got %+v", sess.ClientInfo())
+	}
+}
+
+func TestServer_FeaturesGatedOnVersion(t *testing.T) {
+	tests := []struct {
+		version     string
+		annotations bool
+		structured  bool
+		elicitation bool
+		completions bool
+	}{
+		{ProtocolVersion20241105, false, false, false, false},
+		{ProtocolVersion20250326, true, false, false, true},
+		{ProtocolVersion20250618, true, true, true, true},
+	}
+
+	for _, tt := range tests {
+		server := NewServer()
+		sess := &Session{}
+		response := request(t, server, sess, "initialize", map[string]interface{}{
+			"protocolVersion": tt.version,
+			"capabilities":    map[string]interface{}{"elicitation": map[string]interface{}{}},
+		})
+		capabilities := response.Result.(map[string]interface{})["capabilities"].(ServerCapabilities)
+		if (capabilities.Completions != nil) != tt.completions {
+			t.Errorf("%s: completions capability present=%v, want %v", tt.version, capabilities.Completions != nil, tt.completions)
+		}
+		// Every supported version has logging
+		if capabilities.Logging == nil {
+			t.Errorf("%s: expected the logging capability", tt.version)
+		}
+		if sess.Supports(FeatureElicitation) != tt.elicitation {
+			t.Errorf("%s: elicitation supported=%v, want %v", tt.version, sess.Supports(FeatureElicitation), tt.elicitation)
+		}
+
+		response = server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "tools/list"})
+		for _, tool := range response.Result.(map[string]interface{})["tools"].([]Tool) {
+			if (tool.Annotations != nil) != tt.annotations {
+				t.Errorf("%s: %s annotations = %v, want present=%v", tt.version, tool.Name, tool.Annotations, tt.annotations)
+			}
+			if (tool.OutputSchema != nil) != tt.structured {
+				t.Errorf("%s: %s outputSchema present=%v, want %v", tt.version, tool.Name, tool.OutputSchema != nil, tt.structured)
+			}
+		}
+
+		result := callTool(t, server, sess, "get_colors", nil).Result.(map[string]interface{})
+		if _, ok := result["structuredContent"]; ok != tt.structured {
+			t.Errorf("%s: structuredContent present=%v, want %v", tt.version, ok, tt.structured)
+		}
+
+		// Registered tools keep their full definitions for other sessions
+		if server.tools["get_colors"].tool.OutputSchema == nil || server.tools["get_colors"].tool.Annotations == nil {
+			t.Errorf("%s: expected the registered tool to be left intact", tt.version)
+		}
+	}
+}
+
+func TestServer_SessionLifecycle(t *testing.T) {
+	server := NewServer()
+	sess := server.OpenSession(&Session{Namespace: SessionNamespace("one"), Ephemeral: true})
+
+	response := callTool(t, server, sess, "get_colors", nil)
+	if response.Error == nil || response.Error.Code != -32002 {
+		t.Fatalf("Expected calls before initialize to be rejected, got %+v", response)
+	}
+
+	response = server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "ping"})
+	if response.Error != nil {
+		t.Errorf("Expected ping to be answered before initialize, got: %v", response.Error)
+	}
+
+	initializeSession(t, server, sess, LatestProtocolVersion, nil)
+	response = server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", ID: 3, Method: "initialize"})
+	if response.Error == nil || response.Error.Code != -32600 {
+		t.Errorf("Expected a second initialize to be rejected, got %+v", response)
+	}
+
+	if sess.Initialized() {
+		t.Error("Expected the session not to be ready before notifications/initialized")
+	}
+	if response := callTool(t, server, sess, "get_colors", nil); response.Error == nil || response.Error.Code != -32002 {
+		t.Errorf("Expected calls before notifications/initialized to be rejected, got %+v", response)
+	}
+	server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	if !sess.Initialized() {
+		t.Error("Expected the session to be ready after notifications/initialized")
+	}
+
+	if response := callTool(t, server, sess, "add_color", map[string]interface{}{"color": "blue"}); response.Error != nil {
+		t.Fatalf("Expected no error, got: %v", response.Error)
+	}
+	if names, _ := server.ephemeral.Namespaces(); len(names) != 1 {
+		t.Fatalf("Expected the session namespace, got %q", names)
+	}
+	if names, _ := server.backend.Namespaces(); len(names) != 0 {
+		t.Errorf("Expected the ephemeral namespace to stay out of the backend, got %q", names)
+	}
+	if len(server.Sessions()) != 1 {
+		t.Errorf("Expected 1 open session, got %d", len(server.Sessions()))
+	}
+
+	if err := server.CloseSession(sess); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+	if !sess.Closed() || len(server.Sessions()) != 0 {
+		t.Error("Expected the session to be closed")
+	}
+	if names, _ := server.ephemeral.Namespaces(); len(names) != 0 {
+		t.Errorf("Expected the ephemeral namespace to be dropped, got %q", names)
+	}
+
+	response = server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", ID: 4, Method: "ping"})
+	if response.Error == nil || response.Error.Message != "Session closed" {
+		t.Errorf("Expected requests on a closed session to fail, got %+v", response)
+	}
+	if err := server.CloseSession(sess); err != nil {
+		t.Errorf("Expected closing twice to be a no-op, got %v", err)
+	}
+}
+
+func TestServer_NotificationsAreNotAnswered(t *testing.T) {
+	server := NewServer()
+
+	for _, method := range []string{"notifications/initialized", "ping", "no/such/method"} {
+		if response := server.HandleRequest(context.Background(), JSONRPCRequest{JSONRPC: "2.0", Method: method}); response != nil {
+			t.Errorf("Expected no response to %s notification, got %+v", method, response)
+		}
+	}
+
+	// Notifications still take effect
+	server.HandleRequest(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": "add_color", "arguments": map[string]interface{}{"color": "blue"}},
+	})
+	if text := resultText(t, callTool(t, server, &Session{}, "get_colors", nil)); !strings.Contains(text, "blue") {
+		t.Errorf("Expected the notified call to add blue, got: %s", text)
 	}
 }
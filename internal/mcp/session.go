@@ -0,0 +1,251 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Session identifies the client a request comes from and which favorites
+// it may access, and holds what was negotiated with it by initialize.
+//
+// Sessions registered with Server.OpenSession follow the MCP lifecycle: they
+// only accept ping and the steps of initialization until the client sends
+// notifications/initialized, and nothing once closed. Sessions that were
+// never opened are stateless; every request is accepted on its own, as for
+// requests handled with Server.HandleRequest.
+type Session struct {
+	// Namespace partitions favorites between owners. The empty namespace is
+	// shared by clients that carry no identity.
+	Namespace string
+	// Admin grants access to administrative tools such as list_namespaces
+	Admin bool
+	// Ephemeral sessions own their namespace, which is kept in memory and
+	// dropped when the session is closed
+	Ephemeral bool
+
+	mutex              sync.RWMutex
+	state              sessionState
+	protocolVersion    string
+	clientCapabilities map[string]interface{}
+	clientInfo         ClientInfo
+	send               Sender
+	inFlight           map[string]*inFlightRequest // by requestKey
+	subscriptions      map[string]bool             // resource URIs
+	clientLogLevel     LogLevel                    // 0 until logging/setLevel
+	disabledTools      map[string]bool             // by name, for this session
+}
+
+// Sender delivers a message the server initiates, such as a notification,
+// to the client of a session
+type Sender func(msg JSONRPCRequest) error
+
+// ErrNotConnected is returned when sending to a session whose transport
+// cannot reach the client outside of a response
+var ErrNotConnected = errors.New("session is not connected")
+
+// sessionState is a step of the session lifecycle
+type sessionState int
+
+const (
+	stateStateless    sessionState = iota // never opened, no lifecycle
+	stateNew                              // opened, waiting for initialize
+	stateInitializing                     // initialized, waiting for notifications/initialized
+	stateReady                            // fully initialized
+	stateClosed                           // closed, rejects everything
+)
+
+// admit checks that the session may handle a request for method in its
+// current state
+func (sess *Session) admit(method string) *JSONRPCError {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+
+	switch {
+	case sess.state == stateClosed:
+		return &JSONRPCError{
+			Code:    -32600,
+			Message: "Session closed",
+		}
+	case method == "ping":
+		return nil
+	case sess.state == stateNew && method != "initialize",
+		sess.state == stateInitializing && method != "initialize" && method != "notifications/initialized":
+		return &JSONRPCError{
+			Code:    -32002,
+			Message: "Server not initialized",
+		}
+	}
+	return nil
+}
+
+// beginInitialize moves a new session to initializing, reporting false if
+// it was already initialized. Stateless sessions may initialize any time.
+func (sess *Session) beginInitialize() bool {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	switch sess.state {
+	case stateStateless:
+		return true
+	case stateNew:
+		sess.state = stateInitializing
+		return true
+	}
+	return false
+}
+
+// transition moves the session from one lifecycle state to the next,
+// reporting whether it was in state from
+func (sess *Session) transition(from, to sessionState) bool {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	if sess.state != from {
+		return false
+	}
+	sess.state = to
+	return true
+}
+
+// Initialized reports whether the client has completed initialization by
+// sending notifications/initialized. Stateless sessions always are.
+func (sess *Session) Initialized() bool {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	return sess.state == stateStateless || sess.state == stateReady
+}
+
+// Closed reports whether the session has been closed
+func (sess *Session) Closed() bool {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	return sess.state == stateClosed
+}
+
+// SetSender sets how the session's transport delivers messages the server
+// initiates. Transports that cannot deliver them leave it unset.
+func (sess *Session) SetSender(send Sender) {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	sess.send = send
+}
+
+// Notify sends a notification to the client of the session
+func (sess *Session) Notify(method string, params interface{}) error {
+	sess.mutex.RLock()
+	send, state := sess.send, sess.state
+	sess.mutex.RUnlock()
+
+	if send == nil || state == stateClosed {
+		return ErrNotConnected
+	}
+	return send(JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// ClientInfo identifies the client software of a session
+type ClientInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ProtocolVersion returns the protocol version negotiated by initialize. It
+// is LatestProtocolVersion for sessions that never initialized.
+func (sess *Session) ProtocolVersion() string {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	if sess.protocolVersion == "" {
+		return LatestProtocolVersion
+	}
+	return sess.protocolVersion
+}
+
+// ClientCapabilities returns the capabilities the client declared in
+// initialize, or nil
+func (sess *Session) ClientCapabilities() map[string]interface{} {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	return sess.clientCapabilities
+}
+
+// ClientInfo returns the client software declared in initialize
+func (sess *Session) ClientInfo() ClientInfo {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	return sess.clientInfo
+}
+
+// Supports reports whether a feature may be used with the session: the
+// negotiated protocol version must have it and the client must have
+// declared any capability it needs
+func (sess *Session) Supports(feature Feature) bool {
+	min, ok := featureVersions[feature]
+	if !ok || !versionAtLeast(sess.ProtocolVersion(), min) {
+		return false
+	}
+	if capability, ok := featureCapabilities[feature]; ok {
+		if _, declared := sess.ClientCapabilities()[capability]; !declared {
+			return false
+		}
+	}
+	return true
+}
+
+// SetProtocolVersion sets the protocol version of a session that was
+// negotiated out of band rather than by initialize. It fails if the version
+// is not supported.
+func (sess *Session) SetProtocolVersion(version string) error {
+	for _, supported := range supportedProtocolVersions {
+		if version == supported {
+			sess.mutex.Lock()
+			defer sess.mutex.Unlock()
+			sess.protocolVersion = version
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported protocol version %q", version)
+}
+
+// negotiated records the outcome of initialize
+func (sess *Session) negotiated(version string, capabilities map[string]interface{}, info ClientInfo) {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	sess.protocolVersion = version
+	sess.clientCapabilities = capabilities
+	sess.clientInfo = info
+}
+
+// PrincipalNamespace returns the namespace of an authenticated principal
+func PrincipalNamespace(principal string) string {
+	return "user:" + principal
+}
+
+// UIDNamespace returns the namespace of a local user identified by the
+// operating system rather than by a token, kept apart from principals that
+// happen to share the user's name
+func UIDNamespace(uid uint32) string {
+	return "uid:" + strconv.FormatUint(uint64(uid), 10)
+}
+
+// SessionNamespace returns the namespace of an anonymous client identified
+// only by its Mcp-Session-Id
+func SessionNamespace(sessionID string) string {
+	return "session:" + sessionID
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "testing"
+
+func TestSession_Supports(t *testing.T) {
+	elicitation := map[string]interface{}{"elicitation": map[string]interface{}{}}
+
+	tests := []struct {
+		version      string
+		capabilities map[string]interface{}
+		feature      Feature
+		want         bool
+	}{
+		{ProtocolVersion20241105, nil, FeatureToolAnnotations, false},
+		{ProtocolVersion20250326, nil, FeatureToolAnnotations, true},
+		{ProtocolVersion20250326, nil, FeatureStructuredContent, false},
+		{ProtocolVersion20250618, nil, FeatureStructuredContent, true},
+		{ProtocolVersion20250618, nil, FeatureElicitation, false},
+		{ProtocolVersion20250326, elicitation, FeatureElicitation, false},
+		{ProtocolVersion20250618, elicitation, FeatureElicitation, true},
+		{ProtocolVersion20241105, nil, FeatureProgressMessage, false},
+		{ProtocolVersion20250326, nil, FeatureProgressMessage, true},
+		{ProtocolVersion20241105, nil, FeatureCompletions, false},
+		{ProtocolVersion20250326, nil, FeatureCompletions, true},
+		{ProtocolVersion20241105, nil, FeatureLogging, true},
+		{ProtocolVersion20250618, nil, Feature("unknown"), false},
+	}
+
+	for _, tt := range tests {
+		sess := &Session{}
+		sess.negotiated(tt.version, tt.capabilities, ClientInfo{})
+		if got := sess.Supports(tt.feature); got != tt.want {
+			t.Errorf("Supports(%s) at %s with %v = %v, want %v", tt.feature, tt.version, tt.capabilities, got, tt.want)
+		}
+	}
+}
+
+func TestSession_ProtocolVersion(t *testing.T) {
+	sess := &Session{}
+	if sess.ProtocolVersion() != LatestProtocolVersion {
+		t.Errorf("Expected uninitialized sessions to use %s, got %s", LatestProtocolVersion, sess.ProtocolVersion())
+	}
+
+	if err := sess.SetProtocolVersion(ProtocolVersion20241105); err != nil || sess.ProtocolVersion() != ProtocolVersion20241105 {
+		t.Errorf("Expected %s, got %s (%v)", ProtocolVersion20241105, sess.ProtocolVersion(), err)
+	}
+	if err := sess.SetProtocolVersion("2023-01-01"); err == nil {
+		t.Error("Expected an unsupported version to be rejected")
+	}
+	if sess.ProtocolVersion() != ProtocolVersion20241105 {
+		t.Errorf("Expected a rejected version to leave the session unchanged, got %s", sess.ProtocolVersion())
+	}
+}
+
+func TestSession_Notify(t *testing.T) {
+	sess := &Session{}
+	if err := sess.Notify("notifications/test", nil); err != ErrNotConnected {
+		t.Errorf("Expected ErrNotConnected without a sender, got %v", err)
+	}
+
+	var sent []JSONRPCRequest
+	sess.SetSender(func(msg JSONRPCRequest) error {
+		sent = append(sent, msg)
+		return nil
+	})
+	if err := sess.Notify("notifications/test", map[string]interface{}{"n": 1}); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Method != "notifications/test" || !sent[0].IsNotification() {
+		t.Errorf("Expected one notification, got %+v", sent)
+	}
+
+	server := NewServer()
+	server.OpenSession(sess)
+	if err := server.CloseSession(sess); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+	if err := sess.Notify("notifications/test", nil); err != ErrNotConnected {
+		t.Errorf("Expected closed sessions not to send, got %v", err)
+	}
+}
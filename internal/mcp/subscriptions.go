@@ -0,0 +1,156 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"strings"
+
+	"favorite-colors-mcp/internal/storage"
+)
+
+// notifyingStore is the store of a namespace as seen through a session.
+// Its mutations are logged for the session, and notify the sessions
+// subscribed to the resources they change, whichever session made them.
+type notifyingStore struct {
+	storage.Store
+	server  *Server
+	session *Session
+}
+
+// AddColor adds a color to the favorites list
+func (ns *notifyingStore) AddColor(color string) (string, bool, error) {
+	_, message, added, err := ns.AddFavorite(color, nil, nil)
+	return message, added, err
+}
+
+// AddFavorite adds a color with an optional note and tags, or updates them
+func (ns *notifyingStore) AddFavorite(color string, note *string, tags *[]string) (storage.Favorite, string, bool, error) {
+	fav, message, added, err := ns.Store.AddFavorite(color, note, tags)
+	if added {
+		ns.changed("Added color", []string{fav.ID})
+	}
+	return fav, message, added, err
+}
+
+// RemoveColor removes a color from the favorites list
+func (ns *notifyingStore) RemoveColor(color string) (storage.Favorite, string, bool, error) {
+	fav, message, removed, err := ns.Store.RemoveColor(color)
+	if removed {
+		ns.changed("Removed color", []string{fav.ID})
+	}
+	return fav, message, removed, err
+}
+
+// ClearColors removes all colors
+func (ns *notifyingStore) ClearColors() ([]string, string, error) {
+	ids, message, err := ns.Store.ClearColors()
+	if len(ids) > 0 {
+		ns.changed("Cleared colors", ids)
+	}
+	return ids, message, err
+}
+
+// changed logs a mutation of the favorites with the given IDs and notifies
+// their subscribers, once for the whole mutation
+func (ns *notifyingStore) changed(msg string, ids []string) {
+	ns.server.logEvent(context.Background(), ns.session, LogInfo, "storage", msg, map[string]interface{}{
+		"namespace": ns.session.Namespace,
+		"ids":       ids,
+	})
+	ns.server.resourcesUpdated(ns.session.Namespace, ids)
+}
+
+// resourcesUpdated notifies the sessions of a namespace subscribed to the
+// favorites list, or to one of the favorites with the given IDs, that it
+// changed
+func (s *Server) resourcesUpdated(namespace string, ids []string) {
+	uris := []string{favoritesURI}
+	for _, id := range ids {
+		uris = append(uris, favoriteURIPrefix+id)
+	}
+
+	for _, sess := range s.Sessions() {
+		if sess.Namespace != namespace {
+			continue
+		}
+		for _, uri := range uris {
+			if !sess.subscribed(uri) {
+				continue
+			}
+			// Delivery is best effort; the client rereads the resource
+			// whenever it next hears of a change
+			_ = sess.Notify("notifications/resources/updated", map[string]interface{}{"uri": uri})
+		}
+	}
+}
+
+// subscribed reports whether the session is subscribed to uri
+func (sess *Session) subscribed(uri string) bool {
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	return sess.subscriptions[uri]
+}
+
+// isFavoritesResource reports whether uri names the favorites list or a
+// favorite, which may not exist yet
+func isFavoritesResource(uri string) bool {
+	return uri == favoritesURI || strings.HasPrefix(uri, favoriteURIPrefix) && len(uri) > len(favoriteURIPrefix)
+}
+
+// handleResourcesSubscribe handles resources/subscribe and
+// resources/unsubscribe
+func (s *Server) handleResourcesSubscribe(sess *Session, req JSONRPCRequest, subscribe bool) JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "Resource URI required",
+			},
+		}
+	}
+	if !isFavoritesResource(uri) {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32002,
+				Message: "Resource not found",
+				Data:    map[string]interface{}{"uri": uri},
+			},
+		}
+	}
+
+	sess.mutex.Lock()
+	if subscribe {
+		if sess.subscriptions == nil {
+			sess.subscriptions = make(map[string]bool)
+		}
+		sess.subscriptions[uri] = true
+	} else {
+		delete(sess.subscriptions, uri)
+	}
+	sess.mutex.Unlock()
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
+}
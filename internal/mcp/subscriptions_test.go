@@ -0,0 +1,106 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+// openSubscriber opens and initializes a session of namespace, recording
+// what it is sent
+func openSubscriber(t *testing.T, server *Server, namespace string) (*Session, *recorder) {
+	t.Helper()
+	sess := server.OpenSession(&Session{Namespace: namespace})
+	var sent recorder
+	sess.SetSender(sent.send)
+	initializeSession(t, server, sess, LatestProtocolVersion, nil)
+	server.HandleSessionRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	return sess, &sent
+}
+
+// updatedURIs returns the URIs of the resources/updated notifications sent
+// so far, and forgets them
+func (r *recorder) updatedURIs() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var uris []string
+	for _, msg := range r.messages {
+		if msg.Method == "notifications/resources/updated" {
+			uris = append(uris, msg.Params.(map[string]interface{})["uri"].(string))
+		}
+	}
+	r.messages = nil
+	return uris
+}
+
+func TestServer_ResourceSubscriptions(t *testing.T) {
+	server := NewServer()
+	namespace := PrincipalNamespace("alice")
+	dashboard, sent := openSubscriber(t, server, namespace)
+	editor, _ := openSubscriber(t, server, namespace)
+	other, _ := openSubscriber(t, server, PrincipalNamespace("bob"))
+
+	if response := request(t, server, dashboard, "resources/subscribe", map[string]interface{}{"uri": "colors://favorites"}); response.Error != nil {
+		t.Fatalf("Failed to subscribe: %v", response.Error)
+	}
+
+	// Changes from another session of the namespace are notified
+	resultText(t, callTool(t, server, editor, "add_color", map[string]interface{}{"color": "red"}))
+	if uris := sent.updatedURIs(); len(uris) != 1 || uris[0] != "colors://favorites" {
+		t.Errorf("Expected the list to be updated, got %v", uris)
+	}
+
+	// Other namespaces, and calls that change nothing, are not
+	callTool(t, server, other, "add_color", map[string]interface{}{"color": "blue"})
+	callTool(t, server, editor, "add_color", map[string]interface{}{"color": "red"})
+	callTool(t, server, editor, "remove_color", map[string]interface{}{"color": "green"})
+	if uris := sent.updatedURIs(); len(uris) != 0 {
+		t.Errorf("Expected no updates, got %v", uris)
+	}
+
+	// Single favorites can be subscribed to, before they even exist
+	blue := "colors://favorites/" + newTestFavoriteID(t, server, "blue")
+	request(t, server, dashboard, "resources/subscribe", map[string]interface{}{"uri": blue})
+	request(t, server, dashboard, "resources/unsubscribe", map[string]interface{}{"uri": "colors://favorites"})
+	callTool(t, server, editor, "add_color", map[string]interface{}{"color": "#0000FF"})
+	callTool(t, server, editor, "remove_color", map[string]interface{}{"color": "red"})
+	if uris := sent.updatedURIs(); len(uris) != 1 || uris[0] != blue {
+		t.Errorf("Expected only blue to be updated, got %v", uris)
+	}
+	callTool(t, server, editor, "clear_colors", nil)
+	if uris := sent.updatedURIs(); len(uris) != 1 || uris[0] != blue {
+		t.Errorf("Expected blue to be cleared, got %v", uris)
+	}
+
+	// An import is a single change, however many colors it adds
+	request(t, server, dashboard, "resources/subscribe", map[string]interface{}{"uri": "colors://favorites"})
+	callTool(t, server, editor, "import_colors", map[string]interface{}{"colors": []interface{}{"red", "green", "blue"}})
+	if uris := sent.updatedURIs(); len(uris) != 2 {
+		t.Errorf("Expected the list and blue to be updated once, got %v", uris)
+	}
+
+	if response := request(t, server, dashboard, "resources/subscribe", map[string]interface{}{"uri": "colors://palettes"}); response.Error == nil || response.Error.Code != -32002 {
+		t.Errorf("Expected unknown resources to be refused, got %+v", response)
+	}
+}
+
+// newTestFavoriteID returns the ID color gets once added
+func newTestFavoriteID(t *testing.T, server *Server, color string) string {
+	t.Helper()
+	sess := &Session{Namespace: "scratch"}
+	response := callTool(t, server, sess, "add_color", map[string]interface{}{"color": color})
+	return response.Result.(map[string]interface{})["structuredContent"].(addColorResult).Favorite.ID
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+
+	"favorite-colors-mcp/internal/storage"
+)
+
+// ToolHandler executes a call of a tool. args holds the decoded "arguments"
+// of the call, already checked against the tool's InputSchema. The session
+// and its favorites are available from ctx through SessionFromContext and
+// StoreFromContext. Long-running handlers can report their progress with
+// ReportProgress and should stop once ctx is cancelled.
+//
+// Returning a *JSONRPCError fails the request with that error; any other
+// error is reported to the client as a tool result with isError set.
+type ToolHandler func(ctx context.Context, args map[string]interface{}) (*ToolResult, error)
+
+// ToolResult is the outcome of a tool call
+type ToolResult struct {
+	// Text is shown to the user as the text content of the result
+	Text string
+	// Structured, if not nil, is returned as structuredContent and should
+	// match the tool's OutputSchema
+	Structured interface{}
+	// IsError marks a call that ran but failed
+	IsError bool
+}
+
+// registeredTool is a tool definition together with its handler
+type registeredTool struct {
+	tool        Tool
+	handler     ToolHandler
+	inputSchema *compiledSchema // tool.InputSchema
+}
+
+// callContext is what a tool handler can reach through its context
+type callContext struct {
+	server        *Server
+	session       *Session
+	progressToken interface{} // nil unless the client asked for progress
+}
+
+type callContextKey struct{}
+
+// withCall returns a context carrying the server, session and progress
+// token of a call
+func withCall(ctx context.Context, s *Server, sess *Session, progressToken interface{}) context.Context {
+	return context.WithValue(ctx, callContextKey{}, callContext{server: s, session: sess, progressToken: progressToken})
+}
+
+// SessionFromContext returns the session a tool is called on behalf of, or
+// nil if ctx does not belong to a tool call
+func SessionFromContext(ctx context.Context) *Session {
+	call, ok := ctx.Value(callContextKey{}).(callContext)
+	if !ok {
+		return nil
+	}
+	return call.session
+}
+
+// StoreFromContext returns the favorites of the session a tool is called on
+// behalf of. Its error is a *JSONRPCError that handlers can return as is.
+func StoreFromContext(ctx context.Context) (storage.Store, error) {
+	store, err := storeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// storeFromContext is StoreFromContext for the server's own tools, which
+// may bypass the notifications of the store to group changes
+func storeFromContext(ctx context.Context) (*notifyingStore, error) {
+	call, ok := ctx.Value(callContextKey{}).(callContext)
+	if !ok {
+		return nil, &JSONRPCError{
+			Code:    -32603,
+			Message: "No session",
+		}
+	}
+
+	store, rpcErr := call.server.storeFor(call.session)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return store, nil
+}
+
+// storeFor returns the favorites of a session. Changes made through the
+// store are logged and notify the sessions subscribed to the favorites
+// resources.
+func (s *Server) storeFor(sess *Session) (*notifyingStore, *JSONRPCError) {
+	store, err := s.backendFor(sess).Namespace(sess.Namespace)
+	if err != nil {
+		s.logEvent(context.Background(), sess, LogError, "storage", "Error opening namespace", map[string]interface{}{
+			"namespace": sess.Namespace,
+			"error":     err.Error(),
+		})
+		return nil, &JSONRPCError{
+			Code:    -32603,
+			Message: "Storage unavailable",
+		}
+	}
+	return &notifyingStore{Store: store, server: s, session: sess}, nil
+}
+
+// backendFor returns the backend holding a session's namespace. Ephemeral
+// namespaces end with their session, so they are never persisted.
+func (s *Server) backendFor(sess *Session) storage.Backend {
+	if sess.Ephemeral {
+		return s.ephemeral
+	}
+	return s.backend
+}
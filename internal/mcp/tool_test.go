@@ -0,0 +1,135 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRegisterTool_CustomHandler(t *testing.T) {
+	server := NewServer()
+	sess := &Session{Namespace: PrincipalNamespace("alice")}
+
+	var caller *Session
+	server.RegisterTool(Tool{
+		Name:        "count_colors",
+		Description: "Count favorite colors",
+		InputSchema: ToolSchema{Type: "object"},
+	}, func(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+		caller = SessionFromContext(ctx)
+		store, err := StoreFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &ToolResult{
+			Text:       fmt.Sprintf("%d colors", store.Count()),
+			Structured: map[string]interface{}{"count": store.Count()},
+		}, nil
+	})
+
+	callTool(t, server, sess, "add_color", map[string]interface{}{"color": "blue"})
+	response := callTool(t, server, sess, "count_colors", nil)
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got: %v", response.Error)
+	}
+	if caller != sess {
+		t.Error("Expected the calling session in the context")
+	}
+	result := response.Result.(map[string]interface{})
+	if text := result["content"].([]map[string]interface{})[0]["text"]; text != "1 colors" {
+		t.Errorf("Expected the count of the session's colors, got %v", text)
+	}
+	if structured := result["structuredContent"].(map[string]interface{}); structured["count"] != 1 {
+		t.Errorf("Expected structured count, got %v", structured)
+	}
+
+	// Other sessions see their own favorites through the same handler
+	response = callTool(t, server, &Session{}, "count_colors", nil)
+	if text := response.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"]; text != "0 colors" {
+		t.Errorf("Expected the default namespace to be empty, got %v", text)
+	}
+}
+
+func TestRegisterTool_Errors(t *testing.T) {
+	server := NewServer()
+
+	server.RegisterTool(Tool{Name: "fails", InputSchema: ToolSchema{Type: "object"}},
+		func(context.Context, map[string]interface{}) (*ToolResult, error) {
+			return nil, errors.New("palette unavailable")
+		})
+	server.RegisterTool(Tool{Name: "rejects", InputSchema: ToolSchema{Type: "object"}},
+		func(context.Context, map[string]interface{}) (*ToolResult, error) {
+			return nil, &JSONRPCError{Code: -32602, Message: "Bad palette"}
+		})
+
+	// Plain errors are tool execution failures reported in the result
+	response := callTool(t, server, &Session{}, "fails", nil)
+	if response.Error != nil {
+		t.Fatalf("Expected a result, got error: %v", response.Error)
+	}
+	result := response.Result.(map[string]interface{})
+	if result["isError"] != true || result["content"].([]map[string]interface{})[0]["text"] != "palette unavailable" {
+		t.Errorf("Expected an error result, got %v", result)
+	}
+
+	// JSON-RPC errors fail the request
+	response = callTool(t, server, &Session{}, "rejects", nil)
+	if response.Error == nil || response.Error.Code != -32602 || response.Error.Message != "Bad palette" {
+		t.Errorf("Expected the handler's JSON-RPC error, got %+v", response)
+	}
+}
+
+func TestRegisterTool_Replace(t *testing.T) {
+	server := NewServer()
+
+	server.RegisterTool(Tool{Name: "get_colors", InputSchema: ToolSchema{Type: "object"}},
+		func(context.Context, map[string]interface{}) (*ToolResult, error) {
+			return &ToolResult{Text: "replaced"}, nil
+		})
+
+	response := callTool(t, server, &Session{}, "get_colors", nil)
+	if text := response.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"]; text != "replaced" {
+		t.Errorf("Expected the replacement handler to run, got %v", text)
+	}
+}
+
+func TestRegisterTool_NilHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected RegisterTool to panic on a nil handler")
+		}
+	}()
+	NewServer().RegisterTool(Tool{Name: "broken"}, nil)
+}
+
+func TestRegisterTool_InvalidPatternPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected RegisterTool to panic on an invalid pattern")
+		}
+	}()
+	NewServer().RegisterTool(Tool{
+		Name: "broken",
+		InputSchema: ToolSchema{
+			Type:       "object",
+			Properties: map[string]interface{}{"color": map[string]interface{}{"type": "string", "pattern": "["}},
+		},
+	}, func(context.Context, map[string]interface{}) (*ToolResult, error) {
+		return &ToolResult{Text: "unreachable"}, nil
+	})
+}
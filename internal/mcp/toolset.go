@@ -0,0 +1,120 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "fmt"
+
+// SetToolEnabled enables or disables a tool for every session, such as by
+// configuration. Disabled tools are hidden like admin-only tools are from
+// other sessions. Initialized sessions are sent
+// notifications/tools/list_changed if the set of tools changes. It fails if
+// no tool is registered under name.
+func (s *Server) SetToolEnabled(name string, enabled bool) error {
+	s.toolsMutex.Lock()
+	if _, registered := s.tools[name]; !registered {
+		s.toolsMutex.Unlock()
+		return fmt.Errorf("unknown tool %q", name)
+	}
+	changed := s.disabledTools[name] == enabled
+	if enabled {
+		delete(s.disabledTools, name)
+	} else {
+		s.disabledTools[name] = true
+	}
+	s.toolsMutex.Unlock()
+
+	if changed {
+		s.toolsListChanged(s.Sessions())
+	}
+	return nil
+}
+
+// RestrictToolToAdmins disables a tool for the sessions opened from now on
+// that are not Admin, such as those of principals without the admin role.
+// It fails if no tool is registered under name.
+func (s *Server) RestrictToolToAdmins(name string) error {
+	s.toolsMutex.Lock()
+	defer s.toolsMutex.Unlock()
+	if _, registered := s.tools[name]; !registered {
+		return fmt.Errorf("unknown tool %q", name)
+	}
+	s.adminTools[name] = true
+	return nil
+}
+
+// disableAdminTools disables the tools restricted to admins for a session
+// that is not Admin
+func (s *Server) disableAdminTools(sess *Session) {
+	if sess.Admin {
+		return
+	}
+	s.toolsMutex.RLock()
+	names := make([]string, 0, len(s.adminTools))
+	for name := range s.adminTools {
+		names = append(names, name)
+	}
+	s.toolsMutex.RUnlock()
+
+	for _, name := range names {
+		s.SetSessionToolEnabled(sess, name, false)
+	}
+}
+
+// SetSessionToolEnabled enables or disables a tool for a single session,
+// such as by the role of its principal. A tool disabled for the server
+// stays disabled. The session is sent notifications/tools/list_changed if
+// its set of tools changes.
+func (s *Server) SetSessionToolEnabled(sess *Session, name string, enabled bool) {
+	sess.mutex.Lock()
+	changed := sess.disabledTools[name] == enabled
+	if enabled {
+		delete(sess.disabledTools, name)
+	} else {
+		if sess.disabledTools == nil {
+			sess.disabledTools = make(map[string]bool)
+		}
+		sess.disabledTools[name] = true
+	}
+	sess.mutex.Unlock()
+
+	if changed {
+		s.toolsListChanged([]*Session{sess})
+	}
+}
+
+// toolVisible reports whether the session may see a registered tool. The
+// caller holds toolsMutex.
+func (s *Server) toolVisible(sess *Session, registered registeredTool) bool {
+	name := registered.tool.Name
+	if registered.tool.adminOnly && !sess.Admin || s.disabledTools[name] {
+		return false
+	}
+	sess.mutex.RLock()
+	defer sess.mutex.RUnlock()
+	return !sess.disabledTools[name]
+}
+
+// toolsListChanged tells the initialized sessions among sessions that the
+// tools they can see may have changed
+func (s *Server) toolsListChanged(sessions []*Session) {
+	for _, sess := range sessions {
+		if !sess.Initialized() {
+			continue
+		}
+		// Delivery is best effort; clients list the tools again whenever
+		// they next hear of a change
+		_ = sess.Notify("notifications/tools/list_changed", nil)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"testing"
+)
+
+// listChanged reports how many notifications/tools/list_changed were sent
+// so far, and forgets them
+func (r *recorder) listChanged() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	count := 0
+	for _, msg := range r.messages {
+		if msg.Method == "notifications/tools/list_changed" {
+			count++
+		}
+	}
+	r.messages = nil
+	return count
+}
+
+// toolNames returns the names of the tools the session lists
+func toolNames(t *testing.T, server *Server, sess *Session) map[string]bool {
+	t.Helper()
+	response := request(t, server, sess, "tools/list", nil)
+	names := make(map[string]bool)
+	for _, tool := range response.Result.(map[string]interface{})["tools"].([]Tool) {
+		names[tool.Name] = true
+	}
+	return names
+}
+
+func TestServer_SetToolEnabled(t *testing.T) {
+	server := NewServer()
+	sess, sent := openSubscriber(t, server, "")
+	other, otherSent := openSubscriber(t, server, "")
+	pending := server.OpenSession(&Session{})
+	var pendingSent recorder
+	pending.SetSender(pendingSent.send)
+
+	if err := server.SetToolEnabled("clear_colors", false); err != nil {
+		t.Fatalf("Failed to disable clear_colors: %v", err)
+	}
+	if sent.listChanged() != 1 || otherSent.listChanged() != 1 || pendingSent.listChanged() != 0 {
+		t.Error("Expected the initialized sessions to be notified")
+	}
+	if toolNames(t, server, sess)["clear_colors"] {
+		t.Error("Expected clear_colors to be hidden")
+	}
+	if response := callTool(t, server, sess, "clear_colors", nil); response.Error == nil {
+		t.Error("Expected calls to a disabled tool to fail")
+	}
+
+	// Disabling again changes nothing, and unknown tools are refused
+	server.SetToolEnabled("clear_colors", false)
+	if err := server.SetToolEnabled("clear_color", false); err == nil {
+		t.Error("Expected an unknown tool to be refused")
+	}
+	if sent.listChanged() != 0 {
+		t.Error("Expected no notification without a change")
+	}
+
+	server.SetToolEnabled("clear_colors", true)
+	if sent.listChanged() != 1 || otherSent.listChanged() != 1 || !toolNames(t, server, sess)["clear_colors"] {
+		t.Error("Expected clear_colors back, and a notification")
+	}
+
+	// Disabling for a session leaves the others alone
+	server.SetSessionToolEnabled(sess, "remove_color", false)
+	if sent.listChanged() != 1 || otherSent.listChanged() != 0 {
+		t.Error("Expected only the session to be notified")
+	}
+	if toolNames(t, server, sess)["remove_color"] || !toolNames(t, server, other)["remove_color"] {
+		t.Error("Expected remove_color hidden from the session only")
+	}
+
+	server.RegisterTool(Tool{Name: "mix_colors", InputSchema: ToolSchema{Type: "object"}}, func(ctx context.Context, args map[string]interface{}) (*ToolResult, error) {
+		return &ToolResult{Text: "mixed"}, nil
+	})
+	if sent.listChanged() != 1 || otherSent.listChanged() != 1 {
+		t.Error("Expected registering a tool to notify the sessions")
+	}
+
+	response := request(t, server, &Session{}, "initialize", map[string]interface{}{"protocolVersion": LatestProtocolVersion})
+	if capabilities := response.Result.(map[string]interface{})["capabilities"].(ServerCapabilities); !capabilities.Tools.ListChanged {
+		t.Error("Expected tools.listChanged to be advertised")
+	}
+}
+
+func TestServer_RestrictToolToAdmins(t *testing.T) {
+	server := NewServer()
+	if err := server.RestrictToolToAdmins("clear_colors"); err != nil {
+		t.Fatalf("Failed to restrict clear_colors: %v", err)
+	}
+	if err := server.RestrictToolToAdmins("nope"); err == nil {
+		t.Error("Expected an unknown tool to be refused")
+	}
+
+	user, _ := openSubscriber(t, server, "")
+	admin := server.OpenSession(&Session{Admin: true})
+	initializeSession(t, server, admin, LatestProtocolVersion, nil)
+	server.HandleSessionRequest(context.Background(), admin, JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+
+	if toolNames(t, server, user)["clear_colors"] {
+		t.Error("Expected clear_colors hidden from a session that is not admin")
+	}
+	if response := callTool(t, server, user, "clear_colors", nil); response.Error == nil {
+		t.Error("Expected calls to clear_colors to fail for a session that is not admin")
+	}
+	if !toolNames(t, server, admin)["clear_colors"] {
+		t.Error("Expected clear_colors listed for an admin session")
+	}
+}
+
+func TestServer_SetToolEnabledTool(t *testing.T) {
+	server := NewServer()
+	user, sent := openSubscriber(t, server, "")
+	admin := server.OpenSession(&Session{Admin: true})
+	initializeSession(t, server, admin, LatestProtocolVersion, nil)
+	server.HandleSessionRequest(context.Background(), admin, JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+
+	if toolNames(t, server, user)["set_tool_enabled"] {
+		t.Error("Expected set_tool_enabled hidden from a session that is not admin")
+	}
+	if response := callTool(t, server, user, "set_tool_enabled", map[string]interface{}{"name": "clear_colors", "enabled": false}); response.Error == nil {
+		t.Error("Expected calls to set_tool_enabled to fail for a session that is not admin")
+	}
+
+	response := callTool(t, server, admin, "set_tool_enabled", map[string]interface{}{"name": "clear_colors", "enabled": false})
+	if response.Error != nil || response.Result.(map[string]interface{})["isError"] == true {
+		t.Fatalf("Expected clear_colors to be disabled, got %+v", response)
+	}
+	if sent.listChanged() != 1 || toolNames(t, server, user)["clear_colors"] {
+		t.Error("Expected clear_colors hidden from the other session, and a notification")
+	}
+
+	callTool(t, server, admin, "set_tool_enabled", map[string]interface{}{"name": "clear_colors", "enabled": true})
+	if sent.listChanged() != 1 || !toolNames(t, server, user)["clear_colors"] {
+		t.Error("Expected clear_colors back, and a notification")
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"name": "clear_color", "enabled": false},
+		{"name": "set_tool_enabled", "enabled": false},
+	} {
+		response := callTool(t, server, admin, "set_tool_enabled", args)
+		if response.Error != nil || response.Result.(map[string]interface{})["isError"] != true {
+			t.Errorf("Expected a tool error for %v, got %+v", args, response)
+		}
+	}
+	if !toolNames(t, server, admin)["set_tool_enabled"] {
+		t.Error("Expected set_tool_enabled to stay enabled")
+	}
+}
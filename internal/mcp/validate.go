@@ -0,0 +1,371 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SchemaViolation is one way in which a value does not match a schema
+type SchemaViolation struct {
+	// Pointer is the JSON pointer (RFC 6901) of the offending value
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// schemaMap converts a schema such as a ToolSchema to its generic JSON form
+func schemaMap(schema interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// compiledSchema is a JSON Schema in generic JSON form whose patterns have
+// been compiled, ready to validate values
+type compiledSchema struct {
+	schema   map[string]interface{}
+	patterns map[string]*regexp.Regexp // by source
+}
+
+// compileSchema converts a schema such as a ToolSchema to its generic JSON
+// form and compiles the patterns it declares, failing if one is invalid
+func compileSchema(schema interface{}) (*compiledSchema, error) {
+	m, err := schemaMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiledSchema{schema: m, patterns: make(map[string]*regexp.Regexp)}
+	if err := c.compilePatterns("", m); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// compilePatterns compiles the patterns of a schema and the schemas nested
+// in it
+func (c *compiledSchema) compilePatterns(pointer string, schema map[string]interface{}) error {
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern at %s/pattern: %v", pointer, err)
+		}
+		c.patterns[pattern] = re
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	for name, prop := range props {
+		if prop, ok := prop.(map[string]interface{}); ok {
+			if err := c.compilePatterns(pointer+"/properties/"+escapePointer(name), prop); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"additionalProperties", "items"} {
+		if child, ok := schema[keyword].(map[string]interface{}); ok {
+			if err := c.compilePatterns(pointer+"/"+keyword, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks value against the schema and returns every violation
+// found. It supports the keywords tool schemas use: type, enum, const,
+// properties, required, additionalProperties, items, minItems, maxItems,
+// uniqueItems, minLength, maxLength, pattern, format (date-time), minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum and multipleOf. Unknown
+// keywords are ignored.
+func (c *compiledSchema) validate(value interface{}) []SchemaViolation {
+	v := &validator{patterns: c.patterns}
+	v.check("", c.schema, normalizeJSON(value))
+	return v.violations
+}
+
+type validator struct {
+	patterns   map[string]*regexp.Regexp
+	violations []SchemaViolation
+}
+
+func (v *validator) fail(pointer, format string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) check(pointer string, schema map[string]interface{}, value interface{}) {
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		v.fail(pointer, "expected %s, got %s", typeNames(t), jsonType(value))
+		// Other keywords assume the declared type
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(normalizeJSON(candidate), value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(pointer, "must be one of %s", mustMarshal(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(normalizeJSON(c), value) {
+		v.fail(pointer, "must be %s", mustMarshal(c))
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.checkObject(pointer, schema, value)
+	case []interface{}:
+		v.checkArray(pointer, schema, value)
+	case string:
+		v.checkString(pointer, schema, value)
+	case float64:
+		v.checkNumber(pointer, schema, value)
+	}
+}
+
+func (v *validator) checkObject(pointer string, schema map[string]interface{}, obj map[string]interface{}) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			name, _ := name.(string)
+			if _, ok := obj[name]; !ok {
+				v.fail(pointer, "missing required property %q", name)
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	// Report violations in a stable order
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := pointer + "/" + escapePointer(name)
+		if prop, ok := props[name].(map[string]interface{}); ok {
+			v.check(child, prop, obj[name])
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(child, "unexpected property %q", name)
+			}
+		case map[string]interface{}:
+			v.check(child, additional, obj[name])
+		}
+	}
+}
+
+func (v *validator) checkArray(pointer string, schema map[string]interface{}, items []interface{}) {
+	if n, ok := number(schema["minItems"]); ok && float64(len(items)) < n {
+		v.fail(pointer, "must have at least %v items", n)
+	}
+	if n, ok := number(schema["maxItems"]); ok && float64(len(items)) > n {
+		v.fail(pointer, "must have at most %v items", n)
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(items[i], items[j]) {
+					v.fail(fmt.Sprintf("%s/%d", pointer, i), "duplicates item %d", j)
+					break
+				}
+			}
+		}
+	}
+	if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range items {
+			v.check(fmt.Sprintf("%s/%d", pointer, i), itemSchema, item)
+		}
+	}
+}
+
+func (v *validator) checkString(pointer string, schema map[string]interface{}, s string) {
+	length := float64(utf8.RuneCountInString(s))
+	if n, ok := number(schema["minLength"]); ok && length < n {
+		v.fail(pointer, "must be at least %v characters long", n)
+	}
+	if n, ok := number(schema["maxLength"]); ok && length > n {
+		v.fail(pointer, "must be at most %v characters long", n)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if !v.patterns[pattern].MatchString(s) {
+			v.fail(pointer, "must match pattern %q", pattern)
+		}
+	}
+	if schema["format"] == "date-time" {
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.fail(pointer, "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func (v *validator) checkNumber(pointer string, schema map[string]interface{}, n float64) {
+	if min, ok := number(schema["minimum"]); ok && n < min {
+		v.fail(pointer, "must be >= %v", min)
+	}
+	if max, ok := number(schema["maximum"]); ok && n > max {
+		v.fail(pointer, "must be <= %v", max)
+	}
+	if min, ok := number(schema["exclusiveMinimum"]); ok && n <= min {
+		v.fail(pointer, "must be > %v", min)
+	}
+	if max, ok := number(schema["exclusiveMaximum"]); ok && n >= max {
+		v.fail(pointer, "must be < %v", max)
+	}
+	if m, ok := number(schema["multipleOf"]); ok && m > 0 {
+		if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(pointer, "must be a multiple of %v", m)
+		}
+	}
+}
+
+// matchesType reports whether value has the type, or one of the types, t
+func matchesType(t interface{}, value interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, value)
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok && isType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, value interface{}) bool {
+	switch name {
+	case "":
+		return true
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == name
+	}
+}
+
+// jsonType names the JSON type of a value in generic JSON form
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeNames(t interface{}) string {
+	if names, ok := t.([]interface{}); ok {
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprint(name)
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// number returns a schema keyword's numeric value
+func number(value interface{}) (float64, bool) {
+	n, ok := normalizeJSON(value).(float64)
+	return n, ok
+}
+
+// normalizeJSON converts values built in Go, such as ints or []string, to
+// the generic form encoding/json decodes into. Values already in that form
+// are returned as is.
+func normalizeJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case nil, bool, float64, string:
+		return value
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			out[k] = normalizeJSON(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = normalizeJSON(item)
+		}
+		return out
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case json.Number:
+		n, _ := value.Float64()
+		return n
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return value
+	}
+	return out
+}
+
+// escapePointer escapes a property name for use in a JSON pointer
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func mustMarshal(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func mustSchema(t *testing.T, src string) *compiledSchema {
+	t.Helper()
+	schema, err := compileSchema(json.RawMessage(src))
+	if err != nil {
+		t.Fatalf("Invalid schema %s: %v", src, err)
+	}
+	return schema
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  interface{}
+		want   []string // "pointer: message prefix"
+	}{
+		{"type ok", `{"type":"string"}`, "red", nil},
+		{"type mismatch", `{"type":"string"}`, 3, []string{": expected string, got number"}},
+		{"type union", `{"type":["string","null"]}`, nil, nil},
+		{"integer", `{"type":"integer"}`, 2.5, []string{": expected integer"}},
+		{"integer from int", `{"type":"integer"}`, 2, nil},
+		{"enum", `{"enum":["srgb","p3"]}`, "cmyk", []string{`: must be one of ["srgb","p3"]`}},
+		{"const", `{"const":1}`, 1, nil},
+		{"pattern", `{"type":"string","pattern":"^#[0-9a-f]{6}$"}`, "#12345", []string{": must match pattern"}},
+		{"nested pattern", `{"type":"array","items":{"type":"string","pattern":"^[a-z]+$"}}`, []interface{}{"red", "Red"},
+			[]string{"/1: must match pattern"}},
+		{"minLength", `{"type":"string","minLength":1}`, "", []string{": must be at least 1 characters"}},
+		{"maxLength runes", `{"type":"string","maxLength":2}`, "éé", nil},
+		{"date-time", `{"type":"string","format":"date-time"}`, "yesterday", []string{": must be an RFC 3339"}},
+		{"minimum", `{"type":"number","minimum":0,"maximum":1}`, -1, []string{": must be >= 0"}},
+		{"maximum", `{"type":"number","minimum":0,"maximum":1}`, 1.5, []string{": must be <= 1"}},
+		{"exclusive", `{"exclusiveMinimum":0,"exclusiveMaximum":1}`, 1, []string{": must be < 1"}},
+		{"multipleOf", `{"multipleOf":0.1}`, 0.3, nil},
+		{"items", `{"type":"array","items":{"type":"string"}}`, []interface{}{"a", 1, "b", false},
+			[]string{"/1: expected string", "/3: expected string"}},
+		{"array bounds", `{"type":"array","minItems":1,"maxItems":2,"uniqueItems":true}`, []interface{}{"a", "a", "b"},
+			[]string{": must have at most 2 items", "/1: duplicates item 0"}},
+		{"string slice", `{"type":"array","items":{"type":"string"}}`, []string{"a", "b"}, nil},
+		{
+			"object",
+			`{"type":"object","properties":{"color":{"type":"string"},"a/b":{"type":"integer"}},"required":["color","size"],"additionalProperties":false}`,
+			map[string]interface{}{"color": 1, "a/b": "x", "extra~": true},
+			[]string{`: missing required property "size"`, "/a~1b: expected integer", "/color: expected string", `/extra~0: unexpected property "extra~"`},
+		},
+		{
+			"additional schema",
+			`{"type":"object","additionalProperties":{"type":"number"}}`,
+			map[string]interface{}{"x": 1, "y": "2"},
+			[]string{"/y: expected number"},
+		},
+		{
+			"nested",
+			`{"type":"object","properties":{"palette":{"type":"array","items":{"type":"object","required":["hex"]}}}}`,
+			map[string]interface{}{"palette": []interface{}{map[string]interface{}{}, map[string]interface{}{"hex": "#fff"}}},
+			[]string{`/palette/0: missing required property "hex"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := mustSchema(t, tt.schema).validate(tt.value)
+			if len(violations) != len(tt.want) {
+				t.Fatalf("Expected %d violations, got %v", len(tt.want), violations)
+			}
+			for i, want := range tt.want {
+				got := violations[i].Pointer + ": " + violations[i].Message
+				if !strings.HasPrefix(got, want) {
+					t.Errorf("Violation %d: expected %q, got %q", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileSchema_ToolSchema(t *testing.T) {
+	schema, err := compileSchema(ToolSchema{
+		Type:                 "object",
+		Properties:           map[string]interface{}{"color": map[string]interface{}{"type": "string"}},
+		Required:             []string{"color"},
+		AdditionalProperties: false,
+	})
+	if err != nil {
+		t.Fatalf("Failed to convert schema: %v", err)
+	}
+
+	if v := schema.validate(map[string]interface{}{"color": "red"}); len(v) != 0 {
+		t.Errorf("Expected no violations, got %v", v)
+	}
+	if v := schema.validate(map[string]interface{}{"colour": "red"}); len(v) != 2 {
+		t.Errorf("Expected missing and unexpected property, got %v", v)
+	}
+}
+
+func TestCompileSchema_InvalidPattern(t *testing.T) {
+	_, err := compileSchema(ToolSchema{
+		Type:       "object",
+		Properties: map[string]interface{}{"color": map[string]interface{}{"type": "string", "pattern": "("}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "/properties/color/pattern") {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+}
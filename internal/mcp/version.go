@@ -0,0 +1,95 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+// MCP protocol versions supported by the server
+const (
+	ProtocolVersion20241105 = "2024-11-05"
+	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20250618 = "2025-06-18"
+
+	// LatestProtocolVersion is offered to clients that request a version the
+	// server does not support
+	LatestProtocolVersion = ProtocolVersion20250618
+)
+
+// supportedProtocolVersions lists the supported versions, oldest first
+var supportedProtocolVersions = []string{
+	ProtocolVersion20241105,
+	ProtocolVersion20250326,
+	ProtocolVersion20250618,
+}
+
+// SupportedProtocolVersions returns the protocol versions the server can
+// negotiate, oldest first
+func SupportedProtocolVersions() []string {
+	return append([]string(nil), supportedProtocolVersions...)
+}
+
+// negotiateProtocolVersion picks the version to answer an initialize
+// request for requested with: the requested version if supported, the
+// latest one otherwise. The client disconnects if it cannot use the answer.
+func negotiateProtocolVersion(requested string) string {
+	for _, version := range supportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return LatestProtocolVersion
+}
+
+// Feature is a protocol feature that only some protocol versions or clients
+// support
+type Feature string
+
+// Features gated on the negotiated protocol version
+const (
+	// FeatureToolAnnotations is the annotations field of tools
+	FeatureToolAnnotations Feature = "toolAnnotations"
+	// FeatureStructuredContent is the outputSchema of tools and the
+	// structuredContent of their results
+	FeatureStructuredContent Feature = "structuredContent"
+	// FeatureElicitation is server-initiated elicitation/create requests. It
+	// also requires the client to declare the elicitation capability.
+	FeatureElicitation Feature = "elicitation"
+	// FeatureProgressMessage is the message of progress notifications
+	FeatureProgressMessage Feature = "progressMessage"
+	// FeatureCompletions is the completions capability of the server
+	FeatureCompletions Feature = "completions"
+	// FeatureLogging is the logging capability of the server and the
+	// notifications/message it sends
+	FeatureLogging Feature = "logging"
+)
+
+// featureVersions maps each feature to the first version that has it
+var featureVersions = map[Feature]string{
+	FeatureToolAnnotations:   ProtocolVersion20250326,
+	FeatureStructuredContent: ProtocolVersion20250618,
+	FeatureElicitation:       ProtocolVersion20250618,
+	FeatureProgressMessage:   ProtocolVersion20250326,
+	FeatureCompletions:       ProtocolVersion20250326,
+	FeatureLogging:           ProtocolVersion20241105,
+}
+
+// featureCapabilities maps features to the client capability they need
+var featureCapabilities = map[Feature]string{
+	FeatureElicitation: "elicitation",
+}
+
+// versionAtLeast reports whether version is min or a later one. Versions are
+// dates, so they compare as strings.
+func versionAtLeast(version, min string) bool {
+	return version >= min
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrBackendClosed is returned when a namespace is opened on a closed backend
+var ErrBackendClosed = errors.New("storage: backend is closed")
+
+// namespacedBackend opens the store of each namespace on first use and keeps
+// it open until the backend is closed
+type namespacedBackend struct {
+	open      func(namespace string) (Store, error)
+	persisted func() ([]string, error)     // namespaces stored by earlier runs, may be nil
+	remove    func(namespace string) error // deletes what a dropped namespace leaves behind, may be nil
+	release   func() error                 // called after all stores are closed, may be nil
+
+	mutex  sync.Mutex
+	stores map[string]Store
+	closed bool
+}
+
+// NewBackend returns a Backend that calls open to create the store of each
+// namespace the first time it is used. It suits drivers with nothing to
+// share between namespaces, such as purely in-memory ones.
+func NewBackend(open func(namespace string) (Store, error)) Backend {
+	return newNamespacedBackend(open, nil, nil, nil)
+}
+
+// NewMemoryBackend returns a Backend that keeps every namespace in memory
+func NewMemoryBackend() Backend {
+	return NewBackend(func(string) (Store, error) {
+		return NewColorStorage(), nil
+	})
+}
+
+func newNamespacedBackend(open func(string) (Store, error), persisted func() ([]string, error), remove func(string) error, release func() error) *namespacedBackend {
+	return &namespacedBackend{
+		open:      open,
+		persisted: persisted,
+		remove:    remove,
+		release:   release,
+		stores:    make(map[string]Store),
+	}
+}
+
+// Namespace returns the store of a namespace, opening it on first use
+func (b *namespacedBackend) Namespace(name string) (Store, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil, ErrBackendClosed
+	}
+	if store, ok := b.stores[name]; ok {
+		return store, nil
+	}
+
+	store, err := b.open(name)
+	if err != nil {
+		return nil, err
+	}
+	b.stores[name] = store
+	return store, nil
+}
+
+// Namespaces returns the opened and persisted namespaces in sorted order
+func (b *namespacedBackend) Namespaces() ([]string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	seen := make(map[string]bool, len(b.stores))
+	for name := range b.stores {
+		seen[name] = true
+	}
+	if b.persisted != nil {
+		persisted, err := b.persisted()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range persisted {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Drop clears the store of a namespace, closes it and removes whatever the
+// driver keeps for it
+func (b *namespacedBackend) Drop(name string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return ErrBackendClosed
+	}
+
+	store, ok := b.stores[name]
+	if !ok {
+		var err error
+		if store, err = b.open(name); err != nil {
+			return err
+		}
+	}
+	delete(b.stores, name)
+
+	if _, _, err := store.ClearColors(); err != nil {
+		_ = store.Close()
+		return err
+	}
+	if err := store.Close(); err != nil {
+		return err
+	}
+	if b.remove != nil {
+		return b.remove(name)
+	}
+	return nil
+}
+
+// Close closes every namespace store and then releases shared resources
+func (b *namespacedBackend) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	var errs []error
+	for _, store := range b.stores {
+		if err := store.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	b.stores = nil
+
+	if b.release != nil {
+		if err := b.release(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// escapeNamespace turns a namespace into a single path segment or key
+// component. Everything outside [A-Za-z0-9_-] is percent-encoded, so the
+// result never contains separators and is never "." or "..".
+func escapeNamespace(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte("0123456789ABCDEF"[c>>4])
+		b.WriteByte("0123456789ABCDEF"[c&0xf])
+	}
+	return b.String()
+}
+
+// unescapeNamespace reverses escapeNamespace
+func unescapeNamespace(escaped string) (string, error) {
+	return url.PathUnescape(escaped)
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeNamespace(t *testing.T) {
+	names := []string{"", "user:alice", "..", ".", "a/b", "session:3f2a-9c", "ünïcode"}
+
+	for _, name := range names {
+		escaped := escapeNamespace(name)
+		if strings.ContainsAny(escaped, "/.:") {
+			t.Errorf("escapeNamespace(%q) = %q contains unsafe characters", name, escaped)
+		}
+
+		unescaped, err := unescapeNamespace(escaped)
+		if err != nil || unescaped != name {
+			t.Errorf("unescapeNamespace(%q) = %q, %v; want %q", escaped, unescaped, err, name)
+		}
+	}
+}
+
+func TestBackend_DropFailure(t *testing.T) {
+	cs := NewColorStorage()
+	cs.AddColor("red")
+	cs.persister = failingPersister{}
+	backend := NewBackend(func(string) (Store, error) { return cs, nil })
+
+	if err := backend.Drop("user:alice"); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the failed clear to be returned, got %v", err)
+	}
+}
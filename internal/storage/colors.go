@@ -15,89 +15,328 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
+	"time"
+
+	"favorite-colors-mcp/internal/color"
 )
 
-// ColorStorage manages the favorite colors storage
+// ErrBlankColor is returned when adding a color that is empty or only
+// whitespace
+var ErrBlankColor = errors.New("storage: color must not be blank")
+
+// ColorStorage manages the favorite colors storage. It keeps the list in
+// memory and, for persistent drivers, records every mutation through a
+// persister before applying it.
+//
+// Colors are kept in the spelling the user first added them with, but two
+// spellings of the same sRGB value, such as "Blue" and "#0000ff", are the
+// same favorite.
 type ColorStorage struct {
-	colors []string
-	mutex  sync.RWMutex
+	favorites []Favorite
+	index     map[string]int // favorite ID -> position in favorites
+	mutex     sync.RWMutex
+	persister persister // nil for purely in-memory storage
+}
+
+// persister durably records the mutations of a ColorStorage
+type persister interface {
+	// record stores a mutation before it is applied in memory
+	record(m mutation) error
+	// applied is called with the updated list once a mutation is applied
+	applied(favorites []Favorite)
+	// close flushes any pending state and releases resources
+	close(favorites []Favorite) error
+}
+
+// Mutation operations
+const (
+	opAdd    = "add"
+	opUpdate = "update"
+	opRemove = "remove"
+	opClear  = "clear"
+)
+
+// mutation is a single change to the favorites list. Adds and updates carry
+// the whole record, removes its ID.
+type mutation struct {
+	Seq      uint64    `json:"seq"`
+	Op       string    `json:"op"`
+	Favorite *Favorite `json:"favorite,omitempty"`
+	ID       string    `json:"id,omitempty"`
+
+	// Color is the bare value of mutations stored before favorites were
+	// records; see upgrade
+	Color string `json:"color,omitempty"`
+}
+
+// upgrade converts a mutation stored before favorites were records, dating
+// legacy adds at now. It reports whether m was such a mutation.
+func (m *mutation) upgrade(now time.Time) bool {
+	if m.Color == "" {
+		return false
+	}
+	switch m.Op {
+	case opAdd:
+		fav := newFavorite(m.Color, "", nil, now)
+		m.Favorite = &fav
+	case opRemove:
+		m.ID = favoriteID(canonicalKey(m.Color))
+	}
+	m.Color = ""
+	return true
 }
 
 // NewColorStorage creates a new color storage instance
 func NewColorStorage() *ColorStorage {
 	return &ColorStorage{
-		colors: make([]string, 0),
+		favorites: make([]Favorite, 0),
+		index:     make(map[string]int),
 	}
 }
 
+// canonicalKey returns the value colors are deduplicated on: the canonical
+// sRGB hex of a parseable CSS color, or the trimmed, lower-cased text of
+// anything else.
+func canonicalKey(value string) string {
+	if c, err := color.Parse(value); err == nil {
+		return c.Hex()
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// now returns the time stamped on new and updated favorites
+func now() time.Time {
+	return time.Now().UTC()
+}
+
 // AddColor adds a color to the favorites list
-func (cs *ColorStorage) AddColor(color string) (string, bool) {
+func (cs *ColorStorage) AddColor(color string) (string, bool, error) {
+	_, message, added, err := cs.AddFavorite(color, nil, nil)
+	return message, added, err
+}
+
+// AddFavorite adds a color with an optional note and tags. Adding a color
+// that is already a favorite updates the note or tags given instead; a nil
+// note or tags leaves them unchanged, while an empty one clears them.
+func (cs *ColorStorage) AddFavorite(color string, note *string, tags *[]string) (Favorite, string, bool, error) {
+	if strings.TrimSpace(color) == "" {
+		return Favorite{}, "", false, ErrBlankColor
+	}
+
 	cs.mutex.Lock()
 	defer cs.mutex.Unlock()
 
-	// Check if color already exists
-	for _, existingColor := range cs.colors {
-		if existingColor == color {
-			return fmt.Sprintf("Color '%s' is already in your favorites", color), false
+	var noteText string
+	var tagList []string
+	if note != nil {
+		noteText = *note
+	}
+	if tags != nil {
+		tagList = *tags
+	}
+	fav := newFavorite(color, noteText, tagList, now())
+
+	// Check if color already exists, in this or any other spelling
+	if i, ok := cs.index[fav.ID]; ok {
+		existing := cs.favorites[i]
+		updated := existing.clone()
+		if note != nil {
+			updated.Note = fav.Note
+		}
+		if tags != nil {
+			updated.Tags = fav.Tags
 		}
+		if updated.String() == existing.String() {
+			if existing.Name != color {
+				return updated, fmt.Sprintf("Color '%s' is already in your favorites as '%s'", color, existing.Name), false, nil
+			}
+			return updated, fmt.Sprintf("Color '%s' is already in your favorites", color), false, nil
+		}
+
+		updated.UpdatedAt = fav.UpdatedAt
+		m := mutation{Op: opUpdate, Favorite: &updated}
+		if err := cs.record(m); err != nil {
+			return Favorite{}, "", false, fmt.Errorf("error saving '%s': %w", existing.Name, err)
+		}
+		cs.apply(m)
+		cs.applied()
+		return updated, fmt.Sprintf("Successfully updated '%s' in your favorite colors!", existing.Name), true, nil
+	}
+
+	m := mutation{Op: opAdd, Favorite: &fav}
+	if err := cs.record(m); err != nil {
+		return Favorite{}, "", false, fmt.Errorf("error saving '%s': %w", color, err)
 	}
 
-	cs.colors = append(cs.colors, color)
-	return fmt.Sprintf("Successfully added '%s' to your favorite colors!", color), true
+	cs.apply(m)
+	cs.applied()
+	return fav.clone(), fmt.Sprintf("Successfully added '%s' to your favorite colors!", color), true, nil
 }
 
-// GetColors returns all favorite colors
+// GetColors returns the names of all favorite colors
 func (cs *ColorStorage) GetColors() ([]string, string) {
 	cs.mutex.RLock()
 	defer cs.mutex.RUnlock()
 
-	colors := make([]string, len(cs.colors))
-	copy(colors, cs.colors)
+	colors := make([]string, len(cs.favorites))
+	for i, fav := range cs.favorites {
+		colors[i] = fav.Name
+	}
 
 	var text string
 	if len(colors) == 0 {
 		text = "You have no favorite colors yet."
 	} else {
 		text = fmt.Sprintf("Your favorite colors (%d total):\n", len(colors))
-		for i, color := range colors {
-			text += fmt.Sprintf("%d. %s\n", i+1, color)
+		for i, fav := range cs.favorites {
+			text += fmt.Sprintf("%d. %s\n", i+1, fav)
 		}
 	}
 
 	return colors, text
 }
 
-// RemoveColor removes a color from the favorites list
-func (cs *ColorStorage) RemoveColor(color string) (string, bool) {
+// Favorites returns copies of all favorite records in insertion order
+func (cs *ColorStorage) Favorites() []Favorite {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	return cloneFavorites(cs.favorites)
+}
+
+// RemoveColor removes a color from the favorites list, returning the
+// favorite removed
+func (cs *ColorStorage) RemoveColor(color string) (Favorite, string, bool, error) {
 	cs.mutex.Lock()
 	defer cs.mutex.Unlock()
 
-	for i, existingColor := range cs.colors {
-		if existingColor == color {
-			cs.colors = append(cs.colors[:i], cs.colors[i+1:]...)
-			return fmt.Sprintf("Successfully removed '%s' from your favorite colors!", color), true
-		}
+	// Any spelling of a stored color removes it
+	i, ok := cs.index[favoriteID(canonicalKey(color))]
+	if !ok {
+		return Favorite{}, fmt.Sprintf("Color '%s' was not found in your favorites", color), false, nil
+	}
+	existing := cs.favorites[i]
+
+	m := mutation{Op: opRemove, ID: existing.ID}
+	if err := cs.record(m); err != nil {
+		return Favorite{}, "", false, fmt.Errorf("error removing '%s': %w", existing.Name, err)
 	}
 
-	return fmt.Sprintf("Color '%s' was not found in your favorites", color), false
+	cs.apply(m)
+	cs.applied()
+	return existing, fmt.Sprintf("Successfully removed '%s' from your favorite colors!", existing.Name), true, nil
 }
 
-// ClearColors removes all colors from the favorites list
-func (cs *ColorStorage) ClearColors() (string, int) {
+// ClearColors removes all colors from the favorites list, returning the IDs
+// of the favorites removed
+func (cs *ColorStorage) ClearColors() ([]string, string, error) {
 	cs.mutex.Lock()
 	defer cs.mutex.Unlock()
 
-	clearedCount := len(cs.colors)
-	cs.colors = []string{}
+	m := mutation{Op: opClear}
+	if err := cs.record(m); err != nil {
+		return nil, "", fmt.Errorf("error clearing favorite colors: %w", err)
+	}
+
+	ids := make([]string, len(cs.favorites))
+	for i, fav := range cs.favorites {
+		ids[i] = fav.ID
+	}
+	cs.apply(m)
+	cs.applied()
 
-	return fmt.Sprintf("Successfully cleared %d favorite colors!", clearedCount), clearedCount
+	return ids, fmt.Sprintf("Successfully cleared %d favorite colors!", len(ids)), nil
 }
 
 // Count returns the number of favorite colors
 func (cs *ColorStorage) Count() int {
 	cs.mutex.RLock()
 	defer cs.mutex.RUnlock()
-	return len(cs.colors)
+	return len(cs.favorites)
+}
+
+// Close flushes and releases the resources of a persistent storage. It is a
+// no-op for in-memory storage.
+func (cs *ColorStorage) Close() error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	if cs.persister == nil {
+		return nil
+	}
+
+	err := cs.persister.close(cs.favorites)
+	cs.persister = nil
+	return err
+}
+
+// record persists a mutation before it is applied in memory. The caller
+// must hold the write lock.
+func (cs *ColorStorage) record(m mutation) error {
+	if cs.persister == nil {
+		return nil
+	}
+	if err := cs.persister.record(m); err != nil {
+		log.Printf("Error persisting %s: %v", m.Op, err)
+		return err
+	}
+	return nil
+}
+
+// applied notifies the persister that a mutation has been applied. The
+// caller must hold the write lock.
+func (cs *ColorStorage) applied() {
+	if cs.persister != nil {
+		cs.persister.applied(cs.favorites)
+	}
+}
+
+// apply applies a mutation in memory. It is shared by the live operations
+// and by persisters replaying stored mutations, so both dedupe the same way.
+// The caller must hold the write lock or own cs exclusively.
+func (cs *ColorStorage) apply(m mutation) {
+	switch m.Op {
+	case opAdd:
+		if m.Favorite == nil {
+			return
+		}
+		if _, exists := cs.index[m.Favorite.ID]; exists {
+			return
+		}
+		cs.index[m.Favorite.ID] = len(cs.favorites)
+		cs.favorites = append(cs.favorites, m.Favorite.clone())
+	case opUpdate:
+		if m.Favorite == nil {
+			return
+		}
+		if i, ok := cs.index[m.Favorite.ID]; ok {
+			cs.favorites[i] = m.Favorite.clone()
+		}
+	case opRemove:
+		i, ok := cs.index[m.ID]
+		if !ok {
+			return
+		}
+		cs.favorites = append(cs.favorites[:i], cs.favorites[i+1:]...)
+		delete(cs.index, m.ID)
+		for j := i; j < len(cs.favorites); j++ {
+			cs.index[cs.favorites[j].ID] = j
+		}
+	case opClear:
+		cs.favorites = []Favorite{}
+		cs.index = make(map[string]int)
+	}
+}
+
+// cloneFavorites deep-copies a list of favorites
+func cloneFavorites(favorites []Favorite) []Favorite {
+	out := make([]Favorite, len(favorites))
+	for i, fav := range favorites {
+		out[i] = fav.clone()
+	}
+	return out
 }
@@ -1,6 +1,21 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -10,7 +25,7 @@ func TestColorStorage_AddColor(t *testing.T) {
 	cs := NewColorStorage()
 
 	// Test adding a new color
-	message, added := cs.AddColor("blue")
+	message, added, _ := cs.AddColor("blue")
 	if !added {
 		t.Error("Expected color to be added")
 	}
@@ -19,7 +34,7 @@ func TestColorStorage_AddColor(t *testing.T) {
 	}
 
 	// Test adding duplicate color
-	message, added = cs.AddColor("blue")
+	message, added, _ = cs.AddColor("blue")
 	if added {
 		t.Error("Expected duplicate color not to be added")
 	}
@@ -66,7 +81,7 @@ func TestColorStorage_RemoveColor(t *testing.T) {
 	cs := NewColorStorage()
 
 	// Test removing from empty storage
-	message, removed := cs.RemoveColor("nonexistent")
+	_, message, removed, _ := cs.RemoveColor("nonexistent")
 	if removed {
 		t.Error("Expected color not to be removed from empty storage")
 	}
@@ -76,7 +91,7 @@ func TestColorStorage_RemoveColor(t *testing.T) {
 
 	// Add a color and remove it
 	cs.AddColor("green")
-	message, removed = cs.RemoveColor("green")
+	_, message, removed, _ = cs.RemoveColor("green")
 	if !removed {
 		t.Error("Expected color to be removed")
 	}
@@ -94,9 +109,9 @@ func TestColorStorage_ClearColors(t *testing.T) {
 	cs := NewColorStorage()
 
 	// Test clearing empty storage
-	_, count := cs.ClearColors()
-	if count != 0 {
-		t.Errorf("Expected 0 cleared from empty storage, got %d", count)
+	ids, _, _ := cs.ClearColors()
+	if len(ids) != 0 {
+		t.Errorf("Expected 0 cleared from empty storage, got %d", len(ids))
 	}
 
 	// Add colors and clear
@@ -104,9 +119,9 @@ func TestColorStorage_ClearColors(t *testing.T) {
 	cs.AddColor("blue")
 	cs.AddColor("green")
 
-	message, count := cs.ClearColors()
-	if count != 3 {
-		t.Errorf("Expected 3 colors cleared, got %d", count)
+	ids, message, _ := cs.ClearColors()
+	if len(ids) != 3 {
+		t.Errorf("Expected 3 colors cleared, got %d", len(ids))
 	}
 	if !strings.Contains(message, "Successfully cleared 3") {
 		t.Errorf("Expected clear message with count, got: %s", message)
@@ -118,6 +133,57 @@ func TestColorStorage_ClearColors(t *testing.T) {
 	}
 }
 
+func TestColorStorage_CanonicalDedupe(t *testing.T) {
+	cs := NewColorStorage()
+
+	if _, added, _ := cs.AddColor("Blue"); !added {
+		t.Fatal("Expected Blue to be added")
+	}
+
+	for _, spelling := range []string{"blue", "#0000ff", "#00F", "rgb(0,0,255)", "hsl(240 100% 50%)"} {
+		message, added, _ := cs.AddColor(spelling)
+		if added {
+			t.Errorf("Expected %q to be a duplicate of Blue", spelling)
+		}
+		if !strings.Contains(message, "already in your favorites as 'Blue'") {
+			t.Errorf("Expected message to name the stored spelling, got: %s", message)
+		}
+	}
+
+	// Different alpha is a different color
+	if _, added, _ := cs.AddColor("rgba(0, 0, 255, 0.5)"); !added {
+		t.Error("Expected translucent blue to be a separate favorite")
+	}
+
+	// The original spelling is what gets displayed
+	colors, text := cs.GetColors()
+	if colors[0] != "Blue" || !strings.Contains(text, "1. Blue") {
+		t.Errorf("Expected original spelling Blue, got %v: %s", colors, text)
+	}
+
+	// Any spelling removes the stored color
+	_, message, removed, _ := cs.RemoveColor("#0000FF")
+	if !removed || !strings.Contains(message, "'Blue'") {
+		t.Errorf("Expected #0000FF to remove Blue, got %v: %s", removed, message)
+	}
+	if cs.Count() != 1 {
+		t.Errorf("Expected 1 color left, got %d", cs.Count())
+	}
+}
+
+func TestColorStorage_UnparseableColors(t *testing.T) {
+	cs := NewColorStorage()
+
+	// Values that are not CSS colors are still accepted and deduped by text
+	cs.AddColor("Sunset Orange")
+	if _, added, _ := cs.AddColor("  sunset orange "); added {
+		t.Error("Expected case and whitespace variants of a name to be duplicates")
+	}
+	if _, _, removed, _ := cs.RemoveColor("SUNSET ORANGE"); !removed {
+		t.Error("Expected case-insensitive removal")
+	}
+}
+
 func TestColorStorage_Concurrency(t *testing.T) {
 	cs := NewColorStorage()
 
@@ -143,6 +209,35 @@ func TestColorStorage_Concurrency(t *testing.T) {
 	}
 }
 
+// failingPersister refuses to record any mutation
+type failingPersister struct{}
+
+func (failingPersister) record(mutation) error  { return errors.New("disk full") }
+func (failingPersister) applied([]Favorite)     {}
+func (failingPersister) close([]Favorite) error { return nil }
+
+func TestColorStorage_PersistFailure(t *testing.T) {
+	cs := NewColorStorage()
+	cs.AddColor("red")
+	cs.persister = failingPersister{}
+
+	if _, _, added, err := cs.AddFavorite("blue", nil, nil); added || err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the add to fail, got %v %v", added, err)
+	}
+	if _, _, changed, err := cs.AddFavorite("red", nil, nil); changed || err != nil {
+		t.Errorf("Expected an unchanged favorite not to be saved, got %v %v", changed, err)
+	}
+	if _, _, removed, err := cs.RemoveColor("red"); removed || err == nil {
+		t.Errorf("Expected the removal to fail, got %v %v", removed, err)
+	}
+	if ids, _, err := cs.ClearColors(); len(ids) != 0 || err == nil {
+		t.Errorf("Expected the clear to fail, got %v %v", ids, err)
+	}
+	if colors, _ := cs.GetColors(); len(colors) != 1 || colors[0] != "red" {
+		t.Errorf("Expected failed mutations to change nothing, got %v", colors)
+	}
+}
+
 func BenchmarkColorStorage_AddColor(b *testing.B) {
 	cs := NewColorStorage()
 
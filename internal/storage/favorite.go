@@ -0,0 +1,99 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Favorite is a saved color
+type Favorite struct {
+	// ID identifies the favorite. It is derived from Value, so it is stable
+	// across edits, restarts and drivers.
+	ID string `json:"id"`
+	// Value is the canonical sRGB hex of the color, or the trimmed,
+	// lower-cased text of values that are not CSS colors
+	Value string `json:"value"`
+	// Name is the spelling the color was first added with
+	Name      string    `json:"name"`
+	Note      string    `json:"note,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// idLength is the number of hex digits of a favorite ID
+const idLength = 12
+
+// newFavorite creates the record of a newly added color
+func newFavorite(name, note string, tags []string, now time.Time) Favorite {
+	value := canonicalKey(name)
+	return Favorite{
+		ID:        favoriteID(value),
+		Value:     value,
+		Name:      name,
+		Note:      strings.TrimSpace(note),
+		Tags:      normalizeTags(tags),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// favoriteID derives the ID of a favorite from its canonical value
+func favoriteID(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:idLength]
+}
+
+// normalizeTags trims tags and drops empty and repeated ones, keeping the
+// order they were given in. It returns nil when no tags remain.
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// clone returns a copy of f that shares no memory with it
+func (f Favorite) clone() Favorite {
+	if f.Tags != nil {
+		f.Tags = append([]string(nil), f.Tags...)
+	}
+	return f
+}
+
+// String formats the favorite as a single list line: its name followed by
+// its note and tags, if any
+func (f Favorite) String() string {
+	s := f.Name
+	if f.Note != "" {
+		s += " - " + f.Note
+	}
+	if len(f.Tags) > 0 {
+		s += " [" + strings.Join(f.Tags, ", ") + "]"
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFavoriteID(t *testing.T) {
+	blue := newFavorite("blue", "", nil, time.Time{})
+	hex := newFavorite("#0000FF", "", nil, time.Time{})
+	red := newFavorite("red", "", nil, time.Time{})
+
+	if blue.ID != hex.ID {
+		t.Errorf("Expected spellings of the same color to share an ID, got %s and %s", blue.ID, hex.ID)
+	}
+	if blue.ID == red.ID {
+		t.Error("Expected different colors to have different IDs")
+	}
+	if len(blue.ID) != idLength {
+		t.Errorf("Expected a %d digit ID, got %q", idLength, blue.ID)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{" ", ""}, ""},
+		{[]string{"Warm", "warm", " cool "}, "Warm,cool"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(normalizeTags(tt.tags), ","); got != tt.want {
+			t.Errorf("normalizeTags(%q) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestFavorite_String(t *testing.T) {
+	fav := Favorite{Name: "teal", Note: "accent", Tags: []string{"cool", "ui"}}
+	if got := fav.String(); got != "teal - accent [cool, ui]" {
+		t.Errorf("Unexpected string %q", got)
+	}
+	if got := (Favorite{Name: "teal"}).String(); got != "teal" {
+		t.Errorf("Unexpected string %q", got)
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// On-disk layout of a persistent color storage. The snapshot holds the full
+// list as of a journal sequence number; the journal holds every mutation
+// since, one JSON object per line.
+const (
+	snapshotFile    = "colors.json"
+	snapshotTmpFile = "colors.json.tmp"
+	journalFile     = "journal.log"
+	snapshotVersion = 2
+
+	// legacySnapshotVersion snapshots hold bare color values instead of
+	// favorite records. They are still read and rewritten on open.
+	legacySnapshotVersion = 1
+
+	// namespacesDir holds one subdirectory per namespace other than the
+	// default one, which lives directly in the data directory
+	namespacesDir = "namespaces"
+)
+
+// journalCompactThreshold is the number of journal entries after which the
+// journal is folded into a new snapshot.
+var journalCompactThreshold = 1000
+
+// snapshot is the serialized form of the whole favorites list
+type snapshot struct {
+	Version   int        `json:"version"`
+	Seq       uint64     `json:"seq"`
+	Favorites []Favorite `json:"favorites"`
+
+	// Colors holds the list of legacy snapshots
+	Colors []string `json:"colors,omitempty"`
+}
+
+// journal is the append-only mutation log of a persistent color storage
+type journal struct {
+	dir     string
+	file    *os.File
+	offset  int64  // end of the last complete entry
+	seq     uint64 // sequence number of the last written entry
+	entries int    // entries written since the last snapshot
+	legacy  bool   // whether replay upgraded legacy entries
+	failed  error  // set when a torn entry could not be rolled back
+}
+
+// NewFileColorStorage opens a color storage persisted under dir, creating
+// the directory if needed. The last snapshot is loaded and the journal
+// replayed on top of it; a torn entry left by a crash mid-write is discarded.
+// Data written before favorites were records is upgraded and compacted into
+// a new snapshot right away, so the upgraded timestamps stick.
+func NewFileColorStorage(dir string) (*ColorStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating data directory: %w", err)
+	}
+
+	// A leftover temporary snapshot was never renamed into place, so the
+	// previous snapshot and the journal are still authoritative.
+	if err := os.Remove(filepath.Join(dir, snapshotTmpFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error removing stale snapshot: %w", err)
+	}
+
+	snap, err := readSnapshot(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+
+	cs := NewColorStorage()
+	for i := range snap.Favorites {
+		cs.apply(mutation{Op: opAdd, Favorite: &snap.Favorites[i]})
+	}
+	for _, c := range snap.Colors {
+		m := mutation{Op: opAdd, Color: c}
+		m.upgrade(now())
+		cs.apply(m)
+	}
+
+	j, err := openJournal(dir, snap.Seq, cs.apply)
+	if err != nil {
+		return nil, err
+	}
+
+	if snap.Version == legacySnapshotVersion || j.legacy {
+		if err := j.compact(cs.favorites); err != nil {
+			_ = j.file.Close()
+			return nil, fmt.Errorf("error upgrading storage: %w", err)
+		}
+	}
+
+	cs.persister = j
+	return cs, nil
+}
+
+// newFileBackend opens a backend that keeps each namespace in its own
+// directory under root
+func newFileBackend(root string) (Backend, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating data directory: %w", err)
+	}
+
+	open := func(namespace string) (Store, error) {
+		if namespace == "" {
+			return NewFileColorStorage(root)
+		}
+		return NewFileColorStorage(filepath.Join(root, namespacesDir, escapeNamespace(namespace)))
+	}
+
+	persisted := func() ([]string, error) {
+		var names []string
+		if _, err := os.Stat(filepath.Join(root, journalFile)); err == nil {
+			names = append(names, "")
+		}
+
+		entries, err := os.ReadDir(filepath.Join(root, namespacesDir))
+		if errors.Is(err, os.ErrNotExist) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing namespaces: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			name, err := unescapeNamespace(entry.Name())
+			if err != nil {
+				continue
+			}
+			names = append(names, name)
+		}
+		return names, nil
+	}
+
+	// Clearing leaves an empty snapshot and journal behind
+	remove := func(namespace string) error {
+		if namespace != "" {
+			return os.RemoveAll(filepath.Join(root, namespacesDir, escapeNamespace(namespace)))
+		}
+		for _, name := range []string{snapshotFile, journalFile} {
+			if err := os.Remove(filepath.Join(root, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return newNamespacedBackend(open, persisted, remove, nil), nil
+}
+
+// readSnapshot loads the snapshot at path, returning an empty one if none
+// has been written yet.
+func readSnapshot(path string) (snapshot, error) {
+	snap := snapshot{Version: snapshotVersion, Favorites: []Favorite{}}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- path is derived from the configured data directory
+	if errors.Is(err, os.ErrNotExist) {
+		return snap, nil
+	}
+	if err != nil {
+		return snap, fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("error parsing snapshot %s: %w", path, err)
+	}
+	if snap.Version != snapshotVersion && snap.Version != legacySnapshotVersion {
+		return snap, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	if snap.Favorites == nil {
+		snap.Favorites = []Favorite{}
+	}
+	return snap, nil
+}
+
+// openJournal replays the entries of the journal in dir that follow the
+// snapshot at snapSeq through apply, and opens the journal for appending
+func openJournal(dir string, snapSeq uint64, apply func(mutation)) (*journal, error) {
+	path := filepath.Join(dir, journalFile)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) // #nosec G304 -- path is derived from the configured data directory
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal: %w", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	seq := snapSeq
+	entries := 0
+	valid := 0
+	legacy := false
+
+	for valid < len(data) {
+		end := bytes.IndexByte(data[valid:], '\n')
+		if end < 0 {
+			// Torn final entry: the process died before the newline hit the disk.
+			break
+		}
+
+		var entry mutation
+		if err := json.Unmarshal(data[valid:valid+end], &entry); err != nil {
+			if bytes.IndexByte(data[valid+end+1:], '\n') >= 0 {
+				_ = file.Close()
+				return nil, fmt.Errorf("journal corrupted at offset %d: %w", valid, err)
+			}
+			// Only the last entry is damaged, which is what a crash mid-write looks like.
+			break
+		}
+		valid += end + 1
+
+		// Entries already folded into the snapshot are kept until the journal
+		// is truncated; skip them so they are not applied twice.
+		if entry.Seq <= snapSeq {
+			continue
+		}
+		if entry.upgrade(now()) {
+			legacy = true
+		}
+		apply(entry)
+		seq = entry.Seq
+		entries++
+	}
+
+	if valid < len(data) {
+		if err := file.Truncate(int64(valid)); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("error truncating torn journal entry: %w", err)
+		}
+	}
+	if _, err := file.Seek(int64(valid), io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error seeking journal: %w", err)
+	}
+
+	return &journal{
+		dir:     dir,
+		file:    file,
+		offset:  int64(valid),
+		seq:     seq,
+		entries: entries,
+		legacy:  legacy,
+	}, nil
+}
+
+// record durably appends a mutation to the journal
+func (j *journal) record(entry mutation) error {
+	if j.failed != nil {
+		return j.failed
+	}
+	entry.Seq = j.seq + 1
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if _, err := j.file.Write(line); err != nil {
+		return j.rollback(err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return j.rollback(err)
+	}
+
+	j.offset += int64(len(line))
+	j.seq = entry.Seq
+	j.entries++
+	return nil
+}
+
+// rollback truncates the journal back to its last complete entry after a
+// failed append, so that later entries do not follow torn bytes. If that
+// fails too, the journal refuses further writes until the next snapshot.
+func (j *journal) rollback(err error) error {
+	if truncErr := j.file.Truncate(j.offset); truncErr != nil {
+		j.failed = fmt.Errorf("journal unusable after failed write: %w", truncErr)
+		return err
+	}
+	if _, seekErr := j.file.Seek(j.offset, io.SeekStart); seekErr != nil {
+		j.failed = fmt.Errorf("journal unusable after failed write: %w", seekErr)
+	}
+	return err
+}
+
+// compact writes favorites as a new snapshot and truncates the journal. The
+// snapshot is written to a temporary file and renamed into place, so a crash
+// at any point leaves either the old or the new snapshot intact.
+func (j *journal) compact(favorites []Favorite) error {
+	data, err := json.Marshal(snapshot{
+		Version:   snapshotVersion,
+		Seq:       j.seq,
+		Favorites: favorites,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(j.dir, snapshotFile, snapshotTmpFile, data); err != nil {
+		return err
+	}
+
+	if err := j.file.Truncate(0); err != nil {
+		return fmt.Errorf("error truncating journal: %w", err)
+	}
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error seeking journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing journal: %w", err)
+	}
+
+	// The snapshot holds everything acknowledged, so torn bytes are gone
+	j.offset = 0
+	j.entries = 0
+	j.failed = nil
+	return nil
+}
+
+// applied folds the journal into a fresh snapshot once it has grown past
+// the compaction threshold
+func (j *journal) applied(favorites []Favorite) {
+	if j.entries < journalCompactThreshold {
+		return
+	}
+	if err := j.compact(favorites); err != nil {
+		// The journal is still intact, so nothing is lost; retry next time.
+		log.Printf("Error compacting journal: %v", err)
+	}
+}
+
+// close writes a final snapshot and closes the journal file
+func (j *journal) close(favorites []Favorite) error {
+	err := j.compact(favorites)
+	if closeErr := j.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeFileAtomic replaces dir/name with data via a synced temporary file
+// and a rename, then syncs the directory so the rename itself is durable.
+func writeFileAtomic(dir, name, tmpName string, data []byte) error {
+	tmpPath := filepath.Join(dir, tmpName)
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) // #nosec G304 -- path is derived from the configured data directory
+	if err != nil {
+		return fmt.Errorf("error creating snapshot: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error syncing snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("error renaming snapshot: %w", err)
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir flushes directory metadata such as renames to disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304 -- dir is the configured data directory
+	if err != nil {
+		return fmt.Errorf("error opening data directory: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("error syncing data directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// crashDirEnv tells the test binary to act as the writer process that
+// TestFileColorStorage_KillMidWrite kills.
+const crashDirEnv = "FAVORITE_COLORS_CRASH_DIR"
+
+func TestFileColorStorage_PersistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	cs.AddColor("red")
+	cs.AddColor("blue")
+	cs.AddColor("green")
+	cs.RemoveColor("blue")
+	if err := cs.Close(); err != nil {
+		t.Fatalf("Failed to close storage: %v", err)
+	}
+
+	cs, err = NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer cs.Close()
+
+	colors, _ := cs.GetColors()
+	if strings.Join(colors, ",") != "red,green" {
+		t.Errorf("Expected [red green] after restart, got %v", colors)
+	}
+}
+
+func TestFileColorStorage_ReplaysJournalWithoutClose(t *testing.T) {
+	dir := t.TempDir()
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	cs.AddColor("red")
+	cs.ClearColors()
+	cs.AddColor("purple")
+	// No Close: simulate a process that died without writing a snapshot.
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFile)); !os.IsNotExist(err) {
+		t.Fatalf("Expected no snapshot before compaction, got err=%v", err)
+	}
+
+	recovered, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer recovered.Close()
+
+	colors, _ := recovered.GetColors()
+	if strings.Join(colors, ",") != "purple" {
+		t.Errorf("Expected [purple] after replay, got %v", colors)
+	}
+}
+
+func TestFileColorStorage_TornJournalEntry(t *testing.T) {
+	dir := t.TempDir()
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	cs.AddColor("red")
+	cs.AddColor("blue")
+
+	// Append half of an entry, as if the process died mid-write.
+	f, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	if _, err := f.WriteString(`{"seq":3,"op":"add","col`); err != nil {
+		t.Fatalf("Failed to write torn entry: %v", err)
+	}
+	f.Close()
+
+	recovered, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Expected recovery from torn entry, got: %v", err)
+	}
+
+	colors, _ := recovered.GetColors()
+	if strings.Join(colors, ",") != "red,blue" {
+		t.Errorf("Expected [red blue] after recovery, got %v", colors)
+	}
+
+	// The torn bytes must be gone so new entries are not glued onto them.
+	recovered.AddColor("green")
+	recovered.persister.(*journal).file.Close()
+	recovered.persister = nil
+
+	again, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer again.Close()
+
+	colors, _ = again.GetColors()
+	if strings.Join(colors, ",") != "red,blue,green" {
+		t.Errorf("Expected [red blue green], got %v", colors)
+	}
+}
+
+func TestFileColorStorage_FailedJournalWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	cs.AddColor("red")
+
+	// Part of an entry reached the file before the write failed
+	j := cs.persister.(*journal)
+	if _, err := j.file.WriteString(`{"seq":2,"op":"add","col`); err != nil {
+		t.Fatalf("Failed to write torn entry: %v", err)
+	}
+	failure := errors.New("disk full")
+	if err := j.rollback(failure); err != failure {
+		t.Fatalf("Expected the write error, got %v", err)
+	}
+
+	// Later entries must not be glued onto the torn bytes
+	cs.AddColor("blue")
+	cs.AddColor("green")
+	j.file.Close()
+	cs.persister = nil
+
+	recovered, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer recovered.Close()
+
+	colors, _ := recovered.GetColors()
+	if strings.Join(colors, ",") != "red,blue,green" {
+		t.Errorf("Expected [red blue green], got %v", colors)
+	}
+}
+
+func TestFileColorStorage_CorruptJournal(t *testing.T) {
+	dir := t.TempDir()
+
+	journal := "{\"seq\":1,\"op\":\"add\",\"color\":\"red\"}\nnot json\n{\"seq\":3,\"op\":\"add\",\"color\":\"blue\"}\n"
+	if err := os.WriteFile(filepath.Join(dir, journalFile), []byte(journal), 0o600); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	if _, err := NewFileColorStorage(dir); err == nil {
+		t.Fatal("Expected error for corruption in the middle of the journal")
+	}
+}
+
+func TestFileColorStorage_StaleTempSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	cs.AddColor("red")
+	if err := cs.Close(); err != nil {
+		t.Fatalf("Failed to close storage: %v", err)
+	}
+
+	// A snapshot that was never renamed into place must be ignored.
+	tmp := filepath.Join(dir, snapshotTmpFile)
+	if err := os.WriteFile(tmp, []byte(`{"version":1,"seq":9,"colors":["bogus"`), 0o600); err != nil {
+		t.Fatalf("Failed to write temp snapshot: %v", err)
+	}
+
+	recovered, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer recovered.Close()
+
+	colors, _ := recovered.GetColors()
+	if strings.Join(colors, ",") != "red" {
+		t.Errorf("Expected [red], got %v", colors)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("Expected stale temp snapshot to be removed, got err=%v", err)
+	}
+}
+
+func TestFileColorStorage_CompactionSkipsFoldedEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	cs.AddColor("red")
+	cs.AddColor("blue")
+	cs.RemoveColor("red")
+
+	// Write the snapshot but keep the old journal, as if the process died
+	// between the rename and the truncation.
+	data, err := os.ReadFile(filepath.Join(dir, journalFile))
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if err := cs.Close(); err != nil {
+		t.Fatalf("Failed to close storage: %v", err)
+	}
+	data = append(data, "{\"seq\":4,\"op\":\"add\",\"color\":\"red\"}\n"...)
+	if err := os.WriteFile(filepath.Join(dir, journalFile), data, 0o600); err != nil {
+		t.Fatalf("Failed to restore journal: %v", err)
+	}
+
+	recovered, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer recovered.Close()
+
+	colors, _ := recovered.GetColors()
+	if strings.Join(colors, ",") != "blue,red" {
+		t.Errorf("Expected [blue red], got %v", colors)
+	}
+}
+
+func TestFileColorStorage_KillMidWrite(t *testing.T) {
+	if dir := os.Getenv(crashDirEnv); dir != "" {
+		runCrashWriter(dir)
+		return
+	}
+	if testing.Short() {
+		t.Skip("skipping crash test in short mode")
+	}
+
+	dir := t.TempDir()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFileColorStorage_KillMidWrite$") // #nosec G204 -- re-executes the test binary
+	cmd.Env = append(os.Environ(), crashDirEnv+"="+dir)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start writer: %v", err)
+	}
+
+	// Kill the writer while it is busy appending and compacting.
+	confirmed := make([]string, 0, 300)
+	scanner := bufio.NewScanner(stdout)
+	for len(confirmed) < 300 && scanner.Scan() {
+		confirmed = append(confirmed, scanner.Text())
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatalf("Failed to kill writer: %v", err)
+	}
+	_ = cmd.Wait()
+
+	if len(confirmed) < 300 {
+		t.Fatalf("Writer exited early after %d colors", len(confirmed))
+	}
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to recover after kill: %v", err)
+	}
+	defer cs.Close()
+
+	colors, _ := cs.GetColors()
+	stored := make(map[string]bool, len(colors))
+	for _, color := range colors {
+		stored[color] = true
+	}
+	for _, color := range confirmed {
+		if !stored[color] {
+			t.Errorf("Acknowledged color %s lost after kill", color)
+		}
+	}
+	// Every stored color is one the writer attempted, in order.
+	for i, color := range colors {
+		if color != fmt.Sprintf("color-%d", i) {
+			t.Fatalf("Unexpected color %s at position %d", color, i)
+		}
+	}
+
+	if _, added, _ := cs.AddColor("after-crash"); !added {
+		t.Error("Expected storage to accept writes after recovery")
+	}
+}
+
+// runCrashWriter adds colors to the storage in dir forever, printing each one
+// once AddColor has returned, until the parent test kills the process.
+func runCrashWriter(dir string) {
+	journalCompactThreshold = 7
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for i := 0; ; i++ {
+		color := fmt.Sprintf("color-%d", i)
+		if _, added, _ := cs.AddColor(color); !added {
+			os.Exit(1)
+		}
+		fmt.Println(color)
+	}
+}
+
+func TestFileColorStorage_ReplayDedupesLegacySpellings(t *testing.T) {
+	dir := t.TempDir()
+
+	// A journal written before colors were canonicalized
+	journal := `{"seq":1,"op":"add","color":"Blue"}
+{"seq":2,"op":"add","color":"#0000ff"}
+{"seq":3,"op":"remove","color":"blue"}
+{"seq":4,"op":"add","color":"red"}
+`
+	if err := os.WriteFile(filepath.Join(dir, journalFile), []byte(journal), 0o600); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	defer cs.Close()
+
+	if colors, _ := cs.GetColors(); strings.Join(colors, ",") != "red" {
+		t.Errorf("Expected [red], got %v", colors)
+	}
+}
+
+func TestFileColorStorage_UpgradesLegacySnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	// Data written before favorites were records
+	snap := `{"version":1,"seq":2,"colors":["red","Blue"]}`
+	if err := os.WriteFile(filepath.Join(dir, snapshotFile), []byte(snap), 0o600); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	journal := "{\"seq\":3,\"op\":\"add\",\"color\":\"green\"}\n{\"seq\":4,\"op\":\"remove\",\"color\":\"red\"}\n"
+	if err := os.WriteFile(filepath.Join(dir, journalFile), []byte(journal), 0o600); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	cs, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	want := cs.Favorites()
+	if len(want) != 2 || want[0].Name != "Blue" || want[0].Value != "#0000ff" || want[1].Name != "green" {
+		t.Fatalf("Unexpected favorites: %+v", want)
+	}
+
+	// The upgrade is written out right away, so even a crash keeps the
+	// timestamps it assigned.
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if !strings.Contains(string(data), `"version":2`) {
+		t.Errorf("Expected an upgraded snapshot, got %s", data)
+	}
+
+	recovered, err := NewFileColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer recovered.Close()
+
+	got := recovered.Favorites()
+	if len(got) != 2 || !got[0].CreatedAt.Equal(want[0].CreatedAt) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"favorite-colors-mcp/internal/kv"
+)
+
+// kvFile is the database file of the kv driver inside its directory
+const kvFile = "favorites.kv"
+
+// Key layout. Colors of the default namespace live under kvColorPrefix;
+// those of namespace n under kvNamespacePrefix + escapeNamespace(n) + "/" +
+// kvColorPrefix. Keys end in a zero-padded sequence number so that sorted
+// keys preserve insertion order.
+const (
+	kvColorPrefix     = "color/"
+	kvNamespacePrefix = "ns/"
+)
+
+// kvStore persists a ColorStorage in the embedded key-value database, one
+// key per favorite holding its JSON record
+type kvStore struct {
+	db      *kv.DB
+	prefix  string            // key prefix of the namespace
+	ownsDB  bool              // whether close also closes db
+	keys    map[string]string // favorite ID -> key
+	nextSeq uint64
+}
+
+// NewKVColorStorage opens a color storage persisted in an embedded
+// key-value database under dir, creating the directory if needed
+func NewKVColorStorage(dir string) (*ColorStorage, error) {
+	db, err := openKVDB(dir)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := newKVNamespace(db, "", true)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return cs, nil
+}
+
+// newKVBackend opens a backend that keeps every namespace in one embedded
+// key-value database under dir
+func newKVBackend(dir string) (Backend, error) {
+	db, err := openKVDB(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	open := func(namespace string) (Store, error) {
+		return newKVNamespace(db, namespace, false)
+	}
+
+	persisted := func() ([]string, error) {
+		var names []string
+		if len(db.Keys(kvColorPrefix)) > 0 {
+			names = append(names, "")
+		}
+
+		seen := make(map[string]bool)
+		for _, key := range db.Keys(kvNamespacePrefix) {
+			escaped, _, _ := strings.Cut(strings.TrimPrefix(key, kvNamespacePrefix), "/")
+			if seen[escaped] {
+				continue
+			}
+			seen[escaped] = true
+			if name, err := unescapeNamespace(escaped); err == nil {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+
+	return newNamespacedBackend(open, persisted, nil, db.Close), nil
+}
+
+// openKVDB opens the database of the kv driver in dir
+func openKVDB(dir string) (*kv.DB, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating data directory: %w", err)
+	}
+	return kv.Open(filepath.Join(dir, kvFile))
+}
+
+// newKVNamespace loads the colors of a namespace from db
+func newKVNamespace(db *kv.DB, namespace string, ownsDB bool) (*ColorStorage, error) {
+	store := &kvStore{
+		db:     db,
+		prefix: kvColorPrefix,
+		ownsDB: ownsDB,
+		keys:   make(map[string]string),
+	}
+	if namespace != "" {
+		store.prefix = kvNamespacePrefix + escapeNamespace(namespace) + "/" + kvColorPrefix
+	}
+
+	// Values that are not JSON records are bare colors stored before
+	// favorites were records; rewrite them in place. Spellings that duplicate
+	// an earlier color were stored before colors were canonicalized; drop
+	// them so they cannot resurface after a remove.
+	cs := NewColorStorage()
+	var upgrades kv.Batch
+	for _, key := range db.Keys(store.prefix) {
+		value, _ := db.Get(key)
+
+		m := mutation{Op: opAdd, Favorite: &Favorite{}}
+		legacy := false
+		if err := json.Unmarshal(value, m.Favorite); err != nil || m.Favorite.ID == "" {
+			m = mutation{Op: opAdd, Color: string(value)}
+			legacy = m.upgrade(now())
+		}
+
+		before := cs.Count()
+		cs.apply(m)
+		if cs.Count() == before {
+			upgrades.Delete(key)
+		} else {
+			store.keys[m.Favorite.ID] = key
+			if legacy {
+				data, err := json.Marshal(m.Favorite)
+				if err != nil {
+					return nil, err
+				}
+				upgrades.Put(key, data)
+			}
+		}
+
+		var seq uint64
+		if _, err := fmt.Sscanf(key[len(store.prefix):], "%016x", &seq); err == nil && seq >= store.nextSeq {
+			store.nextSeq = seq + 1
+		}
+	}
+
+	if err := db.Write(&upgrades); err != nil {
+		return nil, err
+	}
+
+	cs.persister = store
+	return cs, nil
+}
+
+// record writes the key changes for a mutation in a single batch
+func (s *kvStore) record(m mutation) error {
+	var batch kv.Batch
+
+	switch m.Op {
+	case opAdd, opUpdate:
+		key, ok := s.keys[m.Favorite.ID]
+		if !ok {
+			key = fmt.Sprintf("%s%016x", s.prefix, s.nextSeq)
+		}
+		data, err := json.Marshal(m.Favorite)
+		if err != nil {
+			return err
+		}
+		batch.Put(key, data)
+		if err := s.db.Write(&batch); err != nil {
+			return err
+		}
+		if !ok {
+			s.nextSeq++
+			s.keys[m.Favorite.ID] = key
+		}
+	case opRemove:
+		key, ok := s.keys[m.ID]
+		if !ok {
+			return nil
+		}
+		batch.Delete(key)
+		if err := s.db.Write(&batch); err != nil {
+			return err
+		}
+		delete(s.keys, m.ID)
+	case opClear:
+		for _, key := range s.keys {
+			batch.Delete(key)
+		}
+		if err := s.db.Write(&batch); err != nil {
+			return err
+		}
+		s.keys = make(map[string]string)
+	}
+
+	return nil
+}
+
+// applied is a no-op: every batch is durable once written
+func (s *kvStore) applied([]Favorite) {}
+
+// close closes the database unless it is shared with other namespaces
+func (s *kvStore) close([]Favorite) error {
+	if !s.ownsDB {
+		return nil
+	}
+	return s.db.Close()
+}
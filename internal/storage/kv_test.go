@@ -0,0 +1,85 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestKVColorStorage_DropsLegacyDuplicates(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := openKVDB(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// Keys written before colors were canonicalized
+	db.Put(kvColorPrefix+"0000000000000000", []byte("Blue"))
+	db.Put(kvColorPrefix+"0000000000000001", []byte("#0000ff"))
+	db.Close()
+
+	cs, err := NewKVColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	cs.RemoveColor("blue")
+	cs.Close()
+
+	cs, err = NewKVColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer cs.Close()
+
+	if cs.Count() != 0 {
+		colors, _ := cs.GetColors()
+		t.Errorf("Expected the duplicate spelling to be gone, got %v", colors)
+	}
+}
+
+func TestKVColorStorage_UpgradesLegacyValues(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := openKVDB(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// A value written before favorites were records
+	db.Put(kvColorPrefix+"0000000000000000", []byte("Teal"))
+	db.Close()
+
+	cs, err := NewKVColorStorage(dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	want := cs.Favorites()
+	cs.Close()
+
+	db, err = openKVDB(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	value, _ := db.Get(kvColorPrefix + "0000000000000000")
+	db.Close()
+
+	var fav Favorite
+	if err := json.Unmarshal(value, &fav); err != nil {
+		t.Fatalf("Expected the value to be rewritten as a record, got %q", value)
+	}
+	if len(want) != 1 || fav.ID != want[0].ID || fav.Name != "Teal" || !fav.CreatedAt.Equal(want[0].CreatedAt) {
+		t.Errorf("Expected %+v, got %+v", want, fav)
+	}
+}
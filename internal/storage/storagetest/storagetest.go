@@ -0,0 +1,585 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storagetest implements the conformance suite that every storage
+// driver must pass.
+package storagetest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"favorite-colors-mcp/internal/storage"
+)
+
+// Suite describes the driver under test
+type Suite struct {
+	// NewDSN returns the DSN of a fresh, empty store
+	NewDSN func(t *testing.T) string
+	// Persistent drivers must return the same colors when a DSN is reopened
+	Persistent bool
+}
+
+// testNamespace is the non-default namespace used by the suite. It contains
+// characters that are not safe in file names or keys.
+const testNamespace = "user:alice/../Bob"
+
+// Run runs the conformance suite against the driver described by s
+func Run(t *testing.T, s Suite) {
+	t.Run("Empty", s.testEmpty)
+	t.Run("AddColor", s.testAddColor)
+	t.Run("RemoveColor", s.testRemoveColor)
+	t.Run("ClearColors", s.testClearColors)
+	t.Run("InsertionOrder", s.testInsertionOrder)
+	t.Run("Canonicalization", s.testCanonicalization)
+	t.Run("Favorites", s.testFavorites)
+	t.Run("UpdateFavorite", s.testUpdateFavorite)
+	t.Run("Concurrency", s.testConcurrency)
+	t.Run("NamespaceIsolation", s.testNamespaceIsolation)
+	t.Run("Namespaces", s.testNamespaces)
+	t.Run("Drop", s.testDrop)
+	if s.Persistent {
+		t.Run("Reopen", s.testReopen)
+		t.Run("ReopenAfterClear", s.testReopenAfterClear)
+		t.Run("ReopenCanonicalization", s.testReopenCanonicalization)
+		t.Run("ReopenFavorites", s.testReopenFavorites)
+		t.Run("ReopenNamespaces", s.testReopenNamespaces)
+		t.Run("ReopenAfterDrop", s.testReopenAfterDrop)
+	}
+}
+
+func (s Suite) openBackend(t *testing.T, dsn string) storage.Backend {
+	t.Helper()
+	backend, err := storage.Open(dsn)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", dsn, err)
+	}
+	return backend
+}
+
+func (s Suite) namespace(t *testing.T, backend storage.Backend, name string) storage.Store {
+	t.Helper()
+	store, err := backend.Namespace(name)
+	if err != nil {
+		t.Fatalf("Failed to open namespace %q: %v", name, err)
+	}
+	return store
+}
+
+// open opens the default namespace of the backend at dsn. Closing the
+// returned store closes the whole backend.
+func (s Suite) open(t *testing.T, dsn string) storage.Store {
+	t.Helper()
+	backend := s.openBackend(t, dsn)
+	return closingStore{Store: s.namespace(t, backend, ""), backend: backend}
+}
+
+func (s Suite) openFresh(t *testing.T) storage.Store {
+	t.Helper()
+	store := s.open(t, s.NewDSN(t))
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// closingStore closes its backend instead of the namespace store
+type closingStore struct {
+	storage.Store
+	backend storage.Backend
+}
+
+func (c closingStore) Close() error {
+	return c.backend.Close()
+}
+
+func (s Suite) testEmpty(t *testing.T) {
+	store := s.openFresh(t)
+
+	if store.Count() != 0 {
+		t.Errorf("Expected empty store, got %d colors", store.Count())
+	}
+	colors, text := store.GetColors()
+	if len(colors) != 0 {
+		t.Errorf("Expected no colors, got %v", colors)
+	}
+	if !strings.Contains(text, "no favorite colors yet") {
+		t.Errorf("Expected empty message, got: %s", text)
+	}
+}
+
+func (s Suite) testAddColor(t *testing.T) {
+	store := s.openFresh(t)
+
+	message, added, _ := store.AddColor("blue")
+	if !added || !strings.Contains(message, "Successfully added") {
+		t.Errorf("Expected blue to be added, got %v: %s", added, message)
+	}
+
+	message, added, _ = store.AddColor("blue")
+	if added || !strings.Contains(message, "already in your favorites") {
+		t.Errorf("Expected duplicate to be rejected, got %v: %s", added, message)
+	}
+
+	for _, blank := range []string{"", "   ", "\t\n"} {
+		if _, added, err := store.AddColor(blank); added || !errors.Is(err, storage.ErrBlankColor) {
+			t.Errorf("Expected %q to be refused, got %v %v", blank, added, err)
+		}
+	}
+
+	if store.Count() != 1 {
+		t.Errorf("Expected 1 color, got %d", store.Count())
+	}
+}
+
+func (s Suite) testRemoveColor(t *testing.T) {
+	store := s.openFresh(t)
+
+	_, message, removed, _ := store.RemoveColor("green")
+	if removed || !strings.Contains(message, "was not found") {
+		t.Errorf("Expected missing color not to be removed, got %v: %s", removed, message)
+	}
+
+	store.AddColor("green")
+	fav, message, removed, err := store.RemoveColor("green")
+	if err != nil {
+		t.Fatalf("Failed to remove green: %v", err)
+	}
+	if !removed || !strings.Contains(message, "Successfully removed") {
+		t.Errorf("Expected green to be removed, got %v: %s", removed, message)
+	}
+	if fav.Name != "green" || fav.ID == "" {
+		t.Errorf("Expected the removed favorite, got %+v", fav)
+	}
+
+	if store.Count() != 0 {
+		t.Errorf("Expected 0 colors after removal, got %d", store.Count())
+	}
+}
+
+func (s Suite) testClearColors(t *testing.T) {
+	store := s.openFresh(t)
+
+	if ids, _, _ := store.ClearColors(); len(ids) != 0 {
+		t.Errorf("Expected 0 cleared from empty store, got %d", len(ids))
+	}
+
+	store.AddColor("red")
+	store.AddColor("blue")
+	store.AddColor("green")
+
+	ids, message, err := store.ClearColors()
+	if err != nil {
+		t.Fatalf("Failed to clear colors: %v", err)
+	}
+	if len(ids) != 3 || !strings.Contains(message, "Successfully cleared 3") {
+		t.Errorf("Expected 3 colors cleared, got %d: %s", len(ids), message)
+	}
+	if store.Count() != 0 {
+		t.Errorf("Expected 0 colors after clear, got %d", store.Count())
+	}
+
+	// The store must stay usable after a clear.
+	if _, added, _ := store.AddColor("red"); !added {
+		t.Error("Expected red to be added after clear")
+	}
+}
+
+func (s Suite) testInsertionOrder(t *testing.T) {
+	store := s.openFresh(t)
+
+	for _, color := range []string{"red", "orange", "yellow", "green"} {
+		store.AddColor(color)
+	}
+	store.RemoveColor("orange")
+	store.AddColor("orange")
+
+	colors, text := store.GetColors()
+	if got := strings.Join(colors, ","); got != "red,yellow,green,orange" {
+		t.Errorf("Expected insertion order red,yellow,green,orange, got %s", got)
+	}
+	if !strings.Contains(text, "4 total") || !strings.Contains(text, "1. red") {
+		t.Errorf("Expected numbered summary, got: %s", text)
+	}
+}
+
+func (s Suite) testCanonicalization(t *testing.T) {
+	store := s.openFresh(t)
+
+	store.AddColor("Blue")
+	if _, added, _ := store.AddColor("rgb(0 0 255)"); added {
+		t.Error("Expected rgb(0 0 255) to duplicate Blue")
+	}
+	if _, _, removed, _ := store.RemoveColor("#00f"); !removed {
+		t.Error("Expected #00f to remove Blue")
+	}
+	if store.Count() != 0 {
+		t.Errorf("Expected empty store, got %d colors", store.Count())
+	}
+}
+
+func (s Suite) testFavorites(t *testing.T) {
+	store := s.openFresh(t)
+
+	fav, _, added, err := store.AddFavorite("Tomato", text(" for the kitchen "), list("warm", " ", "Warm", "red"))
+	if err != nil || !added {
+		t.Fatalf("Expected Tomato to be added, got %v", err)
+	}
+	if fav.ID == "" || fav.Value != "#ff6347" || fav.Name != "Tomato" {
+		t.Errorf("Unexpected record: %+v", fav)
+	}
+	if fav.Note != "for the kitchen" || strings.Join(fav.Tags, ",") != "warm,red" {
+		t.Errorf("Expected trimmed note and deduped tags, got %q %v", fav.Note, fav.Tags)
+	}
+	if fav.CreatedAt.IsZero() || !fav.UpdatedAt.Equal(fav.CreatedAt) {
+		t.Errorf("Expected matching non-zero timestamps, got %v %v", fav.CreatedAt, fav.UpdatedAt)
+	}
+	store.AddColor("blue")
+
+	favorites := store.Favorites()
+	if len(favorites) != 2 || favorites[0].ID != fav.ID || favorites[1].Name != "blue" {
+		t.Fatalf("Unexpected favorites: %+v", favorites)
+	}
+	if favorites[0].ID == favorites[1].ID {
+		t.Error("Expected distinct IDs")
+	}
+	if _, text := store.GetColors(); !strings.Contains(text, "Tomato - for the kitchen [warm, red]") {
+		t.Errorf("Expected note and tags in summary, got: %s", text)
+	}
+
+	// Returned records must not alias the store
+	favorites[0].Tags[0] = "changed"
+	if store.Favorites()[0].Tags[0] != "warm" {
+		t.Error("Expected Favorites to return copies")
+	}
+}
+
+func (s Suite) testUpdateFavorite(t *testing.T) {
+	store := s.openFresh(t)
+
+	original, _, _, _ := store.AddFavorite("blue", text("sky"), nil)
+	if _, message, added, _ := store.AddFavorite("#00f", nil, nil); added || !strings.Contains(message, "already") {
+		t.Errorf("Expected a bare duplicate to be rejected, got %v: %s", added, message)
+	}
+
+	updated, message, changed, err := store.AddFavorite("#00f", nil, list("cool"))
+	if err != nil || !changed || !strings.Contains(message, "updated") {
+		t.Fatalf("Expected tags to update the favorite, got %v %v: %s", changed, err, message)
+	}
+	if updated.ID != original.ID || updated.Name != "blue" || updated.Note != "sky" || strings.Join(updated.Tags, ",") != "cool" {
+		t.Errorf("Unexpected updated record: %+v", updated)
+	}
+	if !updated.CreatedAt.Equal(original.CreatedAt) || updated.UpdatedAt.Before(original.UpdatedAt) {
+		t.Errorf("Unexpected timestamps: %+v", updated)
+	}
+
+	// An empty note or tag list clears it, unlike one left out
+	cleared, _, changed, err := store.AddFavorite("blue", text(""), list())
+	if err != nil || !changed {
+		t.Fatalf("Expected the note and tags to be cleared, got %v %v", changed, err)
+	}
+	if cleared.Note != "" || len(cleared.Tags) != 0 {
+		t.Errorf("Expected no note or tags, got %+v", cleared)
+	}
+	if store.Count() != 1 {
+		t.Errorf("Expected 1 color, got %d", store.Count())
+	}
+}
+
+func (s Suite) testConcurrency(t *testing.T) {
+	store := s.openFresh(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.AddColor(fmt.Sprintf("color-%d", i%10))
+			store.GetColors()
+		}(i)
+	}
+	wg.Wait()
+
+	if store.Count() != 10 {
+		t.Errorf("Expected 10 colors after concurrent adds, got %d", store.Count())
+	}
+}
+
+func (s Suite) testReopen(t *testing.T) {
+	dsn := s.NewDSN(t)
+
+	store := s.open(t, dsn)
+	store.AddColor("red")
+	store.AddColor("blue")
+	store.AddColor("green")
+	store.RemoveColor("blue")
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	store = s.open(t, dsn)
+	defer store.Close()
+
+	colors, _ := store.GetColors()
+	if got := strings.Join(colors, ","); got != "red,green" {
+		t.Errorf("Expected red,green after reopen, got %s", got)
+	}
+
+	// Writes after a reopen must land after the recovered colors.
+	store.AddColor("blue")
+	colors, _ = store.GetColors()
+	if got := strings.Join(colors, ","); got != "red,green,blue" {
+		t.Errorf("Expected red,green,blue, got %s", got)
+	}
+}
+
+func (s Suite) testReopenAfterClear(t *testing.T) {
+	dsn := s.NewDSN(t)
+
+	store := s.open(t, dsn)
+	store.AddColor("red")
+	store.ClearColors()
+	store.AddColor("purple")
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	store = s.open(t, dsn)
+	defer store.Close()
+
+	colors, _ := store.GetColors()
+	if got := strings.Join(colors, ","); got != "purple" {
+		t.Errorf("Expected purple after reopen, got %s", got)
+	}
+}
+
+func (s Suite) testReopenCanonicalization(t *testing.T) {
+	dsn := s.NewDSN(t)
+
+	store := s.open(t, dsn)
+	store.AddColor("Tomato")
+	store.AddColor("#0000FF")
+	store.RemoveColor("tomato")
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	store = s.open(t, dsn)
+	defer store.Close()
+
+	if colors, _ := store.GetColors(); strings.Join(colors, ",") != "#0000FF" {
+		t.Errorf("Expected original spelling #0000FF after reopen, got %v", colors)
+	}
+	if _, added, _ := store.AddColor("blue"); added {
+		t.Error("Expected blue to duplicate #0000FF after reopen")
+	}
+}
+
+func (s Suite) testNamespaceIsolation(t *testing.T) {
+	backend := s.openBackend(t, s.NewDSN(t))
+	defer backend.Close()
+
+	shared := s.namespace(t, backend, "")
+	alice := s.namespace(t, backend, testNamespace)
+	bob := s.namespace(t, backend, "user:bob")
+
+	shared.AddColor("red")
+	alice.AddColor("blue")
+	bob.AddColor("blue")
+	bob.AddColor("green")
+
+	if alice.Count() != 1 || bob.Count() != 2 || shared.Count() != 1 {
+		t.Fatalf("Expected counts 1/2/1, got alice=%d bob=%d default=%d", alice.Count(), bob.Count(), shared.Count())
+	}
+
+	bob.ClearColors()
+	if colors, _ := alice.GetColors(); strings.Join(colors, ",") != "blue" {
+		t.Errorf("Expected clearing bob to leave alice untouched, got %v", colors)
+	}
+	if _, _, removed, _ := alice.RemoveColor("red"); removed {
+		t.Error("Expected red from the default namespace not to be visible to alice")
+	}
+
+	if again := s.namespace(t, backend, testNamespace); again.Count() != 1 {
+		t.Errorf("Expected the same store for a namespace on every call, got %d colors", again.Count())
+	}
+}
+
+func (s Suite) testDrop(t *testing.T) {
+	backend := s.openBackend(t, s.NewDSN(t))
+	defer backend.Close()
+
+	s.namespace(t, backend, "").AddColor("red")
+	s.namespace(t, backend, testNamespace).AddColor("blue")
+	s.namespace(t, backend, "user:bob").AddColor("green")
+
+	if err := backend.Drop(testNamespace); err != nil {
+		t.Fatalf("Failed to drop namespace: %v", err)
+	}
+	// Dropping a namespace that was never used is harmless
+	if err := backend.Drop("user:nobody"); err != nil {
+		t.Fatalf("Failed to drop unused namespace: %v", err)
+	}
+
+	names, err := backend.Namespaces()
+	if err != nil {
+		t.Fatalf("Failed to list namespaces: %v", err)
+	}
+	if got := strings.Join(names, ","); got != ",user:bob" {
+		t.Errorf("Expected [ user:bob] after drop, got %q", names)
+	}
+
+	if store := s.namespace(t, backend, testNamespace); store.Count() != 0 {
+		t.Errorf("Expected a dropped namespace to reopen empty, got %d colors", store.Count())
+	}
+	if store := s.namespace(t, backend, "user:bob"); store.Count() != 1 {
+		t.Errorf("Expected other namespaces to be untouched, got %d colors", store.Count())
+	}
+
+	if err := backend.Drop(""); err != nil {
+		t.Fatalf("Failed to drop the default namespace: %v", err)
+	}
+	if store := s.namespace(t, backend, ""); store.Count() != 0 {
+		t.Errorf("Expected the default namespace to reopen empty, got %d colors", store.Count())
+	}
+}
+
+func (s Suite) testNamespaces(t *testing.T) {
+	backend := s.openBackend(t, s.NewDSN(t))
+	defer backend.Close()
+
+	s.namespace(t, backend, "user:bob").AddColor("green")
+	s.namespace(t, backend, testNamespace).AddColor("blue")
+
+	names, err := backend.Namespaces()
+	if err != nil {
+		t.Fatalf("Failed to list namespaces: %v", err)
+	}
+	if !contains(names, testNamespace) || !contains(names, "user:bob") {
+		t.Errorf("Expected namespaces to include %q and user:bob, got %q", testNamespace, names)
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("Expected sorted namespaces, got %q", names)
+	}
+}
+
+func (s Suite) testReopenNamespaces(t *testing.T) {
+	dsn := s.NewDSN(t)
+
+	backend := s.openBackend(t, dsn)
+	s.namespace(t, backend, testNamespace).AddColor("blue")
+	s.namespace(t, backend, "user:bob").AddColor("green")
+	s.namespace(t, backend, "").AddColor("red")
+	if err := backend.Close(); err != nil {
+		t.Fatalf("Failed to close backend: %v", err)
+	}
+
+	backend = s.openBackend(t, dsn)
+	defer backend.Close()
+
+	names, err := backend.Namespaces()
+	if err != nil {
+		t.Fatalf("Failed to list namespaces: %v", err)
+	}
+	if !contains(names, testNamespace) || !contains(names, "user:bob") || !contains(names, "") {
+		t.Errorf("Expected persisted namespaces after reopen, got %q", names)
+	}
+
+	if colors, _ := s.namespace(t, backend, testNamespace).GetColors(); strings.Join(colors, ",") != "blue" {
+		t.Errorf("Expected blue in %q after reopen, got %v", testNamespace, colors)
+	}
+	if colors, _ := s.namespace(t, backend, "").GetColors(); strings.Join(colors, ",") != "red" {
+		t.Errorf("Expected red in the default namespace after reopen, got %v", colors)
+	}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Suite) testReopenFavorites(t *testing.T) {
+	dsn := s.NewDSN(t)
+
+	store := s.open(t, dsn)
+	store.AddFavorite("teal", text("accent"), list("cool"))
+	store.AddFavorite("teal", nil, list("cool", "ui"))
+	want := store.Favorites()
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	store = s.open(t, dsn)
+	defer store.Close()
+
+	got := store.Favorites()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 favorite after reopen, got %+v", got)
+	}
+	if got[0].ID != want[0].ID || got[0].Note != "accent" || strings.Join(got[0].Tags, ",") != "cool,ui" ||
+		!got[0].CreatedAt.Equal(want[0].CreatedAt) || !got[0].UpdatedAt.Equal(want[0].UpdatedAt) {
+		t.Errorf("Expected %+v after reopen, got %+v", want[0], got[0])
+	}
+}
+
+func (s Suite) testReopenAfterDrop(t *testing.T) {
+	dsn := s.NewDSN(t)
+
+	backend := s.openBackend(t, dsn)
+	s.namespace(t, backend, "").AddColor("red")
+	s.namespace(t, backend, testNamespace).AddColor("blue")
+	if err := backend.Drop(testNamespace); err != nil {
+		t.Fatalf("Failed to drop namespace: %v", err)
+	}
+	if err := backend.Drop(""); err != nil {
+		t.Fatalf("Failed to drop the default namespace: %v", err)
+	}
+	if err := backend.Close(); err != nil {
+		t.Fatalf("Failed to close backend: %v", err)
+	}
+
+	backend = s.openBackend(t, dsn)
+	defer backend.Close()
+
+	names, err := backend.Namespaces()
+	if err != nil {
+		t.Fatalf("Failed to list namespaces: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("Expected no namespaces after reopen, got %q", names)
+	}
+	if store := s.namespace(t, backend, testNamespace); store.Count() != 0 {
+		t.Errorf("Expected the dropped namespace to stay empty, got %d colors", store.Count())
+	}
+}
+
+// text returns a pointer to a note
+func text(note string) *string {
+	return &note
+}
+
+// list returns a pointer to a tag list, empty rather than nil if no tags
+// are given
+func list(tags ...string) *[]string {
+	if tags == nil {
+		tags = []string{}
+	}
+	return &tags
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store is the interface implemented by favorite color storage backends.
+// Implementations must be safe for concurrent use. Mutations return an
+// error when they cannot be saved, in which case nothing changed.
+type Store interface {
+	// AddColor adds a color, reporting whether it was not already present
+	AddColor(color string) (string, bool, error)
+	// AddFavorite adds a color with an optional note and tags, or updates
+	// the note and tags of a color already present. A nil note or tags
+	// leaves them unchanged and an empty one clears them. It reports
+	// whether the favorites changed. Blank colors are refused with
+	// ErrBlankColor.
+	AddFavorite(color string, note *string, tags *[]string) (Favorite, string, bool, error)
+	// GetColors returns the names of all colors in insertion order and a
+	// summary
+	GetColors() ([]string, string)
+	// Favorites returns all favorite records in insertion order
+	Favorites() []Favorite
+	// RemoveColor removes a color, returning the favorite removed and
+	// reporting whether it was present
+	RemoveColor(color string) (Favorite, string, bool, error)
+	// ClearColors removes all colors, returning the IDs of the favorites
+	// removed
+	ClearColors() ([]string, string, error)
+	// Count returns the number of colors
+	Count() int
+	// Close releases the resources held by the store
+	Close() error
+}
+
+// ColorStorage is the Store shared by the built-in drivers
+var _ Store = (*ColorStorage)(nil)
+
+// Backend holds the independent favorites stores of every namespace. The
+// empty namespace is the default one used when callers are not partitioned.
+// Implementations must be safe for concurrent use.
+type Backend interface {
+	// Namespace returns the store of a namespace, creating it on first use.
+	// The store is owned by the backend and must not be closed by callers.
+	Namespace(name string) (Store, error)
+	// Namespaces returns the names of the namespaces opened in this process
+	// or persisted by earlier ones, in sorted order
+	Namespaces() ([]string, error)
+	// Drop deletes the favorites of a namespace and closes its store, which
+	// callers must no longer use. The namespace is recreated empty if it is
+	// opened again.
+	Drop(name string) error
+	// Close closes every namespace store and releases the backend
+	Close() error
+}
+
+// Driver opens backends for a storage technology
+type Driver interface {
+	// Open opens a backend at location, the part of the DSN after the driver
+	// name. Drivers that need no location should refuse one, rather than
+	// let callers believe the favorites are kept there.
+	Open(location string) (Backend, error)
+}
+
+// DriverFunc adapts a function to the Driver interface
+type DriverFunc func(location string) (Backend, error)
+
+// Open calls f(location)
+func (f DriverFunc) Open(location string) (Backend, error) {
+	return f(location)
+}
+
+var (
+	driversMutex sync.RWMutex
+	drivers      = make(map[string]Driver)
+)
+
+// Built-in drivers
+func init() {
+	Register("memory", DriverFunc(func(location string) (Backend, error) {
+		if location != "" {
+			return nil, fmt.Errorf("memory storage keeps nothing on disk and takes no directory, got %q", location)
+		}
+		return NewMemoryBackend(), nil
+	}))
+	Register("file", DriverFunc(func(location string) (Backend, error) {
+		if location == "" {
+			return nil, fmt.Errorf("file storage requires a directory, e.g. file:./data")
+		}
+		return newFileBackend(location)
+	}))
+	Register("kv", DriverFunc(func(location string) (Backend, error) {
+		if location == "" {
+			return nil, fmt.Errorf("kv storage requires a directory, e.g. kv:./data")
+		}
+		return newKVBackend(location)
+	}))
+}
+
+// Register makes a storage driver available under name. It panics if name
+// is empty, driver is nil or a driver is already registered under name.
+func Register(name string, driver Driver) {
+	driversMutex.Lock()
+	defer driversMutex.Unlock()
+
+	if name == "" || strings.ContainsAny(name, ":/") {
+		panic(fmt.Sprintf("storage: invalid driver name %q", name))
+	}
+	if driver == nil {
+		panic("storage: Register driver is nil")
+	}
+	if _, exists := drivers[name]; exists {
+		panic("storage: Register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers returns the names of the registered drivers in sorted order
+func Drivers() []string {
+	driversMutex.RLock()
+	defer driversMutex.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens a backend from a DSN of the form "driver", "driver:location" or
+// "driver://location", e.g. "memory", "file:./data" or "kv:///var/lib/colors".
+func Open(dsn string) (Backend, error) {
+	name, location := ParseDSN(dsn)
+
+	driversMutex.RLock()
+	driver, ok := drivers[name]
+	driversMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown storage driver %q (available: %s)", name, strings.Join(Drivers(), ", "))
+	}
+
+	backend, err := driver.Open(location)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s storage: %w", name, err)
+	}
+	return backend, nil
+}
+
+// ParseDSN splits a DSN into its driver name and location
+func ParseDSN(dsn string) (name, location string) {
+	name, location, _ = strings.Cut(dsn, ":")
+	return name, strings.TrimPrefix(location, "//")
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage_test
+
+import (
+	"strings"
+	"testing"
+
+	"favorite-colors-mcp/internal/storage"
+	"favorite-colors-mcp/internal/storage/storagetest"
+)
+
+func TestMemoryDriver(t *testing.T) {
+	storagetest.Run(t, storagetest.Suite{
+		NewDSN: func(*testing.T) string { return "memory" },
+	})
+}
+
+func TestFileDriver(t *testing.T) {
+	storagetest.Run(t, storagetest.Suite{
+		NewDSN:     func(t *testing.T) string { return "file:" + t.TempDir() },
+		Persistent: true,
+	})
+}
+
+func TestKVDriver(t *testing.T) {
+	storagetest.Run(t, storagetest.Suite{
+		NewDSN:     func(t *testing.T) string { return "kv://" + t.TempDir() },
+		Persistent: true,
+	})
+}
+
+func TestOpen_UnknownDriver(t *testing.T) {
+	_, err := storage.Open("postgres://localhost/colors")
+	if err == nil {
+		t.Fatal("Expected error for unknown driver")
+	}
+	if !strings.Contains(err.Error(), "memory") {
+		t.Errorf("Expected error to list available drivers, got: %v", err)
+	}
+}
+
+func TestOpen_MissingLocation(t *testing.T) {
+	for _, dsn := range []string{"file", "kv:"} {
+		if _, err := storage.Open(dsn); err == nil {
+			t.Errorf("Expected error for %q without a directory", dsn)
+		}
+	}
+}
+
+func TestOpen_UnexpectedLocation(t *testing.T) {
+	// As from -storage=memory -data-dir=./data, which would persist nothing
+	if _, err := storage.Open("memory:./data"); err == nil {
+		t.Error("Expected error for memory storage with a directory")
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn, name, location string
+	}{
+		{"memory", "memory", ""},
+		{"file:./data", "file", "./data"},
+		{"kv:///var/lib/colors", "kv", "/var/lib/colors"},
+		{"file://data", "file", "data"},
+	}
+
+	for _, tt := range tests {
+		name, location := storage.ParseDSN(tt.dsn)
+		if name != tt.name || location != tt.location {
+			t.Errorf("ParseDSN(%q) = %q, %q; want %q, %q", tt.dsn, name, location, tt.name, tt.location)
+		}
+	}
+}
+
+func TestRegister_CustomDriver(t *testing.T) {
+	storage.Register("test-custom", storage.DriverFunc(func(string) (storage.Backend, error) {
+		return storage.NewBackend(func(string) (storage.Store, error) {
+			return storage.NewColorStorage(), nil
+		}), nil
+	}))
+
+	found := false
+	for _, name := range storage.Drivers() {
+		found = found || name == "test-custom"
+	}
+	if !found {
+		t.Fatalf("Expected test-custom in %v", storage.Drivers())
+	}
+
+	storagetest.Run(t, storagetest.Suite{
+		NewDSN: func(*testing.T) string { return "test-custom" },
+	})
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic when registering a driver twice")
+		}
+	}()
+	storage.Register("memory", storage.DriverFunc(func(string) (storage.Backend, error) {
+		return nil, nil
+	}))
+}
+
+func TestBackend_ClosedBackend(t *testing.T) {
+	backend, err := storage.Open("memory")
+	if err != nil {
+		t.Fatalf("Failed to open backend: %v", err)
+	}
+	if err := backend.Close(); err != nil {
+		t.Fatalf("Failed to close backend: %v", err)
+	}
+
+	if _, err := backend.Namespace("user:alice"); err == nil {
+		t.Error("Expected error opening a namespace on a closed backend")
+	}
+}
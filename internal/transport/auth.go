@@ -0,0 +1,126 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+// Principal is an authenticated client
+type Principal struct {
+	Name  string
+	Admin bool
+	// Namespace holds the principal's favorites, PrincipalNamespace(Name)
+	// if empty
+	Namespace string
+}
+
+// namespace returns the favorites namespace of the principal
+func (p Principal) namespace() string {
+	if p.Namespace != "" {
+		return p.Namespace
+	}
+	return mcp.PrincipalNamespace(p.Name)
+}
+
+// TokenAuthenticator authenticates HTTP clients by bearer token
+type TokenAuthenticator struct {
+	tokens map[string]Principal
+}
+
+// NewTokenAuthenticator creates an authenticator for the given token to
+// principal mapping
+func NewTokenAuthenticator(tokens map[string]Principal) *TokenAuthenticator {
+	return &TokenAuthenticator{tokens: tokens}
+}
+
+// LoadTokenFile reads bearer tokens from a file with one
+// "token principal [admin]" entry per line. Blank lines and lines starting
+// with # are ignored.
+func LoadTokenFile(path string) (*TokenAuthenticator, error) {
+	file, err := os.Open(path) // #nosec G304 -- path is chosen by the operator
+	if err != nil {
+		return nil, fmt.Errorf("error opening token file: %w", err)
+	}
+	defer file.Close()
+
+	tokens := make(map[string]Principal)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 || (len(fields) == 3 && fields[2] != "admin") {
+			return nil, fmt.Errorf("%s:%d: expected \"token principal [admin]\"", path, lineNum)
+		}
+		if _, exists := tokens[fields[0]]; exists {
+			return nil, fmt.Errorf("%s:%d: duplicate token", path, lineNum)
+		}
+
+		tokens[fields[0]] = Principal{
+			Name:  fields[1],
+			Admin: len(fields) == 3,
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading token file: %w", err)
+	}
+
+	return NewTokenAuthenticator(tokens), nil
+}
+
+// Authenticate returns the principal of the bearer token in the request's
+// Authorization header
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (Principal, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return Principal{}, false
+	}
+	return a.AuthenticateToken(token)
+}
+
+// AuthenticateToken returns the principal of a bearer token
+func (a *TokenAuthenticator) AuthenticateToken(token string) (Principal, bool) {
+	if token == "" {
+		return Principal{}, false
+	}
+
+	// Compare against every token so timing does not reveal a near match.
+	var found Principal
+	matched := false
+	for candidate, principal := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			found = principal
+			matched = true
+		}
+	}
+	return found, matched
+}
+
+// unauthorized answers a request that lacks a valid bearer token
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="favorite-colors-mcp"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	content := "# token principal [admin]\n\ns3cret alice\nr00t root admin\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	auth, err := LoadTokenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load token file: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer r00t")
+	principal, ok := auth.Authenticate(req)
+	if !ok || principal.Name != "root" || !principal.Admin {
+		t.Errorf("Expected admin root, got %+v (ok=%v)", principal, ok)
+	}
+
+	req.Header.Set("Authorization", "Bearer s3cret")
+	principal, ok = auth.Authenticate(req)
+	if !ok || principal.Name != "alice" || principal.Admin {
+		t.Errorf("Expected non-admin alice, got %+v (ok=%v)", principal, ok)
+	}
+
+	for _, header := range []string{"", "Bearer ", "Bearer wrong", "Basic s3cret"} {
+		req.Header.Set("Authorization", header)
+		if _, ok := auth.Authenticate(req); ok {
+			t.Errorf("Expected %q to be rejected", header)
+		}
+	}
+}
+
+func TestLoadTokenFile_Invalid(t *testing.T) {
+	for _, content := range []string{"lonely-token\n", "tok alice superuser\n", "tok alice\ntok bob\n"} {
+		path := filepath.Join(t.TempDir(), "tokens")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write token file: %v", err)
+		}
+		if _, err := LoadTokenFile(path); err == nil {
+			t.Errorf("Expected error for token file %q", content)
+		}
+	}
+}
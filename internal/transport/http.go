@@ -16,19 +16,40 @@ package transport
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"favorite-colors-mcp/internal/mcp"
 )
 
+const (
+	// sessionIdleTimeout is how long a session may go without requests or
+	// open streams before it is closed
+	sessionIdleTimeout = 30 * time.Minute
+	// sessionSweepInterval is how often idle sessions are looked for
+	sessionSweepInterval = time.Minute
+	// maxSessions bounds the sessions open at once
+	maxSessions = 1000
+	// maxBodyBytes bounds the body of a message posted to the server
+	maxBodyBytes = 1 << 20
+)
+
+// errTooManySessions is returned when a session would exceed maxSessions
+var errTooManySessions = errors.New("too many sessions")
+
 // HTTPTransport handles HTTP/HTTPS-based communication
 type HTTPTransport struct {
 	server   *mcp.Server
@@ -36,40 +57,131 @@ type HTTPTransport struct {
 	useHTTPS bool
 	certFile string
 	keyFile  string
+	auth     *TokenAuthenticator
+
+	idleTimeout time.Duration
+	maxSessions int
+
+	sessionsMutex sync.Mutex
+	sessions      map[string]*httpSession // by Mcp-Session-Id
+}
+
+// httpSession is an MCP session established by an initialize request
+type httpSession struct {
+	session   *mcp.Session
+	principal string // authenticated principal that owns the session, if any
+	events    *eventLog
+	legacy    bool // a session of the HTTP+SSE transport
+
+	// Guarded by the transport's sessionsMutex
+	lastUsed time.Time
+	busy     int // requests and streams in progress
+
+	streamMutex  sync.Mutex
+	streamCancel context.CancelFunc // ends the GET stream being served
+}
+
+// newHTTPSession wraps session, buffering the messages it is sent for the
+// GET stream
+func newHTTPSession(session *mcp.Session, principal string) *httpSession {
+	hs := &httpSession{
+		session:   session,
+		principal: principal,
+		events:    newEventLog(),
+	}
+	session.SetSender(func(msg mcp.JSONRPCRequest) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		hs.events.append(standaloneStream, data)
+		return nil
+	})
+	return hs
+}
+
+// takeStream makes the caller the only server of the GET stream, ending
+// any other. The returned context is done when the stream is taken over.
+func (hs *httpSession) takeStream(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	hs.streamMutex.Lock()
+	defer hs.streamMutex.Unlock()
+	if hs.streamCancel != nil {
+		hs.streamCancel()
+	}
+	hs.streamCancel = cancel
+	return ctx, cancel
+}
+
+// close ends the session's streams
+func (hs *httpSession) close() {
+	hs.events.close()
 }
 
 // NewHTTPTransport creates a new HTTP transport
 func NewHTTPTransport(port string, useHTTPS bool, certFile, keyFile string) *HTTPTransport {
+	return NewHTTPTransportWithServer(mcp.NewServer(), port, useHTTPS, certFile, keyFile)
+}
+
+// NewHTTPTransportWithServer creates a new HTTP transport serving the given server
+func NewHTTPTransportWithServer(server *mcp.Server, port string, useHTTPS bool, certFile, keyFile string) *HTTPTransport {
 	return &HTTPTransport{
-		server:   mcp.NewServer(),
+		server:   server,
 		port:     port,
 		useHTTPS: useHTTPS,
 		certFile: certFile,
 		keyFile:  keyFile,
+
+		idleTimeout: sessionIdleTimeout,
+		maxSessions: maxSessions,
+		sessions:    make(map[string]*httpSession),
 	}
 }
 
-// Run starts the HTTP transport server
-func (ht *HTTPTransport) Run() error {
-	// Create HTTP server with proper configuration
+// SetAuthenticator requires every MCP request to carry a bearer token known
+// to auth. Each authenticated principal gets its own favorites namespace.
+func (ht *HTTPTransport) SetAuthenticator(auth *TokenAuthenticator) {
+	ht.auth = auth
+}
+
+// handler routes the endpoints of the transport
+func (ht *HTTPTransport) handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// Add CORS middleware to all endpoints
 	mux.HandleFunc("/", corsHandler(ht.handleRoot))
 	mux.HandleFunc("/mcp", corsHandler(ht.handleMCP))
 
+	// HTTP+SSE transport of protocol version 2024-11-05
+	mux.HandleFunc("/sse", corsHandler(ht.handleLegacyStream))
+	mux.HandleFunc("/messages", corsHandler(ht.handleLegacyMessage))
+
 	// Add OAuth protected resource endpoint for MCP Inspector
 	mux.HandleFunc("/.well-known/oauth-protected-resource", corsHandler(ht.handleOAuthResource))
+	return mux
+}
 
+// Run starts the HTTP transport server
+func (ht *HTTPTransport) Run() error {
+	// Create HTTP server with proper configuration
 	httpServer := &http.Server{
 		Addr:              ht.port,
-		Handler:           mux,
+		Handler:           ht.handler(),
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      30 * time.Second,
 		IdleTimeout:       60 * time.Second,
 	}
 
+	// Open streams would otherwise hold up a graceful shutdown
+	httpServer.RegisterOnShutdown(ht.endStreams)
+
+	// Sessions clients stopped using without deleting them are closed
+	sweepDone := make(chan struct{})
+	defer close(sweepDone)
+	go ht.sweepSessions(sweepDone)
+
 	// Channel to listen for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -86,13 +198,17 @@ func (ht *HTTPTransport) Run() error {
 		log.Println("Endpoints:")
 		log.Println("  GET  / - Server information")
 		log.Println("  POST /mcp - StreamableHttp endpoint for MCP Inspector")
+		log.Println("  GET  /mcp - SSE stream of server messages for the session")
+		log.Println("  DELETE /mcp - End the session named by Mcp-Session-Id")
+		log.Println("  GET  /sse - HTTP+SSE stream for 2024-11-05 clients")
+		log.Println("  POST /messages - HTTP+SSE message endpoint")
 		log.Println("  GET  /.well-known/oauth-protected-resource - OAuth resource info")
 		log.Println()
 		log.Println("MCP Inspector configuration:")
 		log.Println("  Transport Type: StreamableHttp")
 		log.Printf("  URL: %s://localhost%s/mcp", protocol, ht.port)
 		log.Println()
-		log.Println("Available tools: add_color, get_colors, remove_color, clear_colors")
+		log.Println("Available tools: add_color, import_colors, get_colors, remove_color, clear_colors")
 		log.Println()
 		log.Println("Press CTRL+C to shutdown gracefully...")
 
@@ -123,6 +239,7 @@ func (ht *HTTPTransport) Run() error {
 	if err := httpServer.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
+	ht.closeSessions()
 
 	log.Println("Server shutdown gracefully")
 	return nil
@@ -134,50 +251,464 @@ func (ht *HTTPTransport) handleMCP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, Mcp-Session-Id, MCP-Protocol-Version, Last-Event-ID")
+	w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
+	if r.Method == "DELETE" {
+		ht.handleDeleteSession(w, r)
+		return
+	}
+
+	if r.Method == "GET" {
+		ht.handleStream(w, r)
+		return
+	}
+
 	if r.Method != "POST" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Simple POST handling for MCP Inspector
-	var req mcp.JSONRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("JSON decode error: %v", err)
-		errorResp := mcp.JSONRPCResponse{
-			JSONRPC: "2.0",
-			ID:      nil,
-			Error: &mcp.JSONRPCError{
-				Code:    -32700,
-				Message: "Parse error",
-				Data:    err.Error(),
-			},
+	principal, ok := ht.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+
+	msg, rpcErr := mcp.ParseMessage(body)
+	if rpcErr != nil {
+		log.Printf("Invalid JSON-RPC message: %s", rpcErr.Message)
+		ht.writeReply(w, mcp.JSONRPCResponse{JSONRPC: "2.0", Error: rpcErr})
+		return
+	}
+
+	hs, ok := ht.sessionFor(w, r, principal, msg.Method())
+	if !ok {
+		return
+	}
+	release := ht.use(hs)
+	defer release()
+
+	for _, req := range msg.Requests {
+		logRequest(req)
+	}
+
+	if !msg.NeedsReply() {
+		// Only notifications, which are never answered
+		ht.server.HandleSessionMessage(r.Context(), hs.session, msg)
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if acceptsEventStream(r) {
+		ht.streamReply(w, r, hs, msg)
+		return
+	}
+
+	// The request is cancelled if the client disconnects before the reply
+	reply := msg.Reply(ht.server.HandleSessionMessage(r.Context(), hs.session, msg))
+	if reply == nil {
+		// Every request was cancelled
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	ht.writeReply(w, reply)
+}
+
+// streamReply answers a message with an SSE stream that ends with the reply.
+// The message is handled to completion even if the client disconnects, so
+// that it can resume the stream from a GET request; it is only cancelled by
+// notifications/cancelled or the end of the session.
+func (ht *HTTPTransport) streamReply(w http.ResponseWriter, r *http.Request, hs *httpSession, msg *mcp.Message) {
+	ctx := context.WithoutCancel(r.Context())
+
+	// Notifications related to the message, such as progress, go on its
+	// stream ahead of the reply
+	stream := hs.events.openStream()
+	release := ht.use(hs)
+	ctx = mcp.WithSender(ctx, func(msg mcp.JSONRPCRequest) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		hs.events.append(stream, data)
+		return nil
+	})
+	go func() {
+		defer release()
+		defer hs.events.finish(stream)
+		reply := msg.Reply(ht.server.HandleSessionMessage(ctx, hs.session, msg))
+		if reply == nil {
+			return
 		}
-		if err := json.NewEncoder(w).Encode(errorResp); err != nil {
-			log.Printf("Error encoding error response: %v", err)
+		data, err := json.Marshal(reply)
+		if err != nil {
+			log.Printf("Response encoding error: %v", err)
+			return
 		}
+		hs.events.append(stream, data)
+	}()
+
+	serveEvents(w, hs.events, stream, 0, r.Context().Done())
+}
+
+// handleStream serves the GET stream of a session, on which the server
+// sends messages of its own. With Last-Event-ID it instead resumes the
+// stream that event was sent on, replaying the events missed since.
+func (ht *HTTPTransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	principal, ok := ht.authenticate(w, r)
+	if !ok {
+		return
+	}
+	if !acceptsEventStream(r) {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
 		return
 	}
 
-	log.Printf("Processing MCP request: method=%s, id=%v", req.Method, req.ID)
+	hs, ok := ht.lookupSession(w, r, principal)
+	if !ok {
+		return
+	}
+	release := ht.use(hs)
+	defer release()
+
+	stream, after := uint64(standaloneStream), hs.events.last()
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		after = id
+		// Events no longer buffered can only be resumed on the GET stream
+		if s, ok := hs.events.streamOf(id); ok {
+			stream = s
+		}
+	}
 
-	response := ht.server.HandleRequest(req)
+	ctx := r.Context()
+	if stream == standaloneStream {
+		var cancel context.CancelFunc
+		ctx, cancel = hs.takeStream(ctx)
+		defer cancel()
+	}
+	serveEvents(w, hs.events, stream, after, ctx.Done())
+}
 
-	log.Printf("Sending MCP response for method=%s", req.Method)
+// readBody reads the body of a posted message, up to maxBodyBytes. It
+// writes an error response and returns false if the body cannot be read or
+// is too large.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Error reading request", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+// writeReply sends a JSON-RPC response or batch of responses
+func (ht *HTTPTransport) writeReply(w http.ResponseWriter, reply interface{}) {
+	if err := json.NewEncoder(w).Encode(reply); err != nil {
 		log.Printf("Response encoding error: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
 
+// authenticate checks the bearer token of a request if authentication is
+// configured, returning the principal, or nil if authentication is off. It
+// writes a 401 response and returns false if the token is missing or
+// unknown.
+func (ht *HTTPTransport) authenticate(w http.ResponseWriter, r *http.Request) (*Principal, bool) {
+	// Connections identified by the socket itself, such as by peer
+	// credentials, need no token
+	if principal, ok := r.Context().Value(connPrincipalKey{}).(*Principal); ok {
+		return principal, true
+	}
+	if ht.auth == nil {
+		return nil, true
+	}
+	principal, ok := ht.auth.Authenticate(r)
+	if !ok {
+		unauthorized(w)
+		return nil, false
+	}
+	return &principal, true
+}
+
+// sessionFor returns the session of an MCP request:
+//   - an initialize request without Mcp-Session-Id starts a new session,
+//     whose ID is returned in the Mcp-Session-Id response header;
+//   - a request with Mcp-Session-Id continues that session;
+//   - any other request is refused, as every request but initialize
+//     belongs to a session.
+//
+// Authenticated principals use their own namespace in every session;
+// anonymous sessions get an ephemeral namespace that is dropped with the
+// session.
+//
+// It writes an error response and returns false if the session is missing
+// or unknown, or the protocol version is not supported.
+func (ht *HTTPTransport) sessionFor(w http.ResponseWriter, r *http.Request, principal *Principal, method string) (*httpSession, bool) {
+	version := r.Header.Get("MCP-Protocol-Version")
+	if version != "" && !supportedVersion(version) {
+		http.Error(w, fmt.Sprintf("Unsupported protocol version %q", version), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if r.Header.Get("Mcp-Session-Id") != "" || method != "initialize" {
+		return ht.lookupSession(w, r, principal)
+	}
+
+	sessionID, hs, err := ht.openSession(principal, false)
+	if errors.Is(err, errTooManySessions) {
+		http.Error(w, "Too many sessions", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error opening session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	w.Header().Set("Mcp-Session-Id", sessionID)
+	return hs, true
+}
+
+// openSession starts a session for principal, which owns it if not nil.
+// Anonymous sessions get a namespace of their own. It returns
+// errTooManySessions if maxSessions are open even after closing idle ones.
+func (ht *HTTPTransport) openSession(principal *Principal, legacy bool) (string, *httpSession, error) {
+	ht.sessionsMutex.Lock()
+	full := len(ht.sessions) >= ht.maxSessions
+	ht.sessionsMutex.Unlock()
+	if full {
+		// Make room ahead of the next sweep
+		ht.expireSessions()
+	}
+
+	sessionID, err := newSessionID()
+	if err != nil {
+		return "", nil, fmt.Errorf("error generating session ID: %w", err)
+	}
+
+	session := &mcp.Session{
+		Namespace: mcp.SessionNamespace(sessionID),
+		Ephemeral: true,
+	}
+	if principal != nil {
+		session.Namespace = principal.namespace()
+		session.Admin = principal.Admin
+		session.Ephemeral = false
+	}
+
+	ht.sessionsMutex.Lock()
+	defer ht.sessionsMutex.Unlock()
+	if len(ht.sessions) >= ht.maxSessions {
+		return "", nil, errTooManySessions
+	}
+	hs := newHTTPSession(ht.server.OpenSession(session), principalName(principal))
+	hs.legacy = legacy
+	hs.lastUsed = time.Now()
+	ht.sessions[sessionID] = hs
+	return sessionID, hs, nil
+}
+
+// lookupSession returns the session named by the Mcp-Session-Id header
+func (ht *HTTPTransport) lookupSession(w http.ResponseWriter, r *http.Request, principal *Principal) (*httpSession, bool) {
+	sessionID := r.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		http.Error(w, "Mcp-Session-Id header required", http.StatusBadRequest)
+		return nil, false
+	}
+	return ht.findSession(w, sessionID, principal, false)
+}
+
+// findSession returns a session of the given transport if it belongs to
+// principal
+func (ht *HTTPTransport) findSession(w http.ResponseWriter, sessionID string, principal *Principal, legacy bool) (*httpSession, bool) {
+	ht.sessionsMutex.Lock()
+	defer ht.sessionsMutex.Unlock()
+	hs, ok := ht.sessions[sessionID]
+
+	// Sessions are bound to the principal that created them
+	if !ok || hs.legacy != legacy || hs.principal != principalName(principal) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+	hs.lastUsed = time.Now()
+	return hs, true
+}
+
+// use marks a session busy until the returned function is called. Busy
+// sessions do not expire.
+func (ht *HTTPTransport) use(hs *httpSession) (release func()) {
+	ht.sessionsMutex.Lock()
+	defer ht.sessionsMutex.Unlock()
+	hs.busy++
+	return func() {
+		ht.sessionsMutex.Lock()
+		defer ht.sessionsMutex.Unlock()
+		hs.busy--
+		hs.lastUsed = time.Now()
+	}
+}
+
+// sweepSessions closes idle sessions every sessionSweepInterval until done
+// is closed
+func (ht *HTTPTransport) sweepSessions(done <-chan struct{}) {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ht.expireSessions()
+		case <-done:
+			return
+		}
+	}
+}
+
+// expireSessions closes the sessions that have not been busy for
+// idleTimeout, dropping the favorites of anonymous ones
+func (ht *HTTPTransport) expireSessions() {
+	cutoff := time.Now().Add(-ht.idleTimeout)
+
+	ht.sessionsMutex.Lock()
+	expired := make(map[string]*httpSession)
+	for sessionID, hs := range ht.sessions {
+		if hs.busy == 0 && hs.lastUsed.Before(cutoff) {
+			expired[sessionID] = hs
+			delete(ht.sessions, sessionID)
+		}
+	}
+	ht.sessionsMutex.Unlock()
+
+	for sessionID, hs := range expired {
+		hs.close()
+		if err := ht.server.CloseSession(hs.session); err != nil {
+			log.Printf("Error closing session: %v", err)
+		}
+		log.Printf("HTTP session expired: %s", sessionID)
+	}
+}
+
+// handleDeleteSession terminates the session named by the Mcp-Session-Id
+// header, as clients do when they no longer need it
+func (ht *HTTPTransport) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	principal, ok := ht.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID := r.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		http.Error(w, "Mcp-Session-Id header required", http.StatusBadRequest)
+		return
+	}
+
+	ht.sessionsMutex.Lock()
+	hs, ok := ht.sessions[sessionID]
+	// HTTP+SSE sessions end with their stream instead
+	owned := ok && !hs.legacy && hs.principal == principalName(principal)
+	if owned {
+		delete(ht.sessions, sessionID)
+	}
+	ht.sessionsMutex.Unlock()
+
+	if !owned {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	hs.close()
+	if err := ht.server.CloseSession(hs.session); err != nil {
+		log.Printf("Error closing session: %v", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// closeSessions closes every open session, as when the server shuts down
+func (ht *HTTPTransport) closeSessions() {
+	ht.sessionsMutex.Lock()
+	sessions := ht.sessions
+	ht.sessions = make(map[string]*httpSession)
+	ht.sessionsMutex.Unlock()
+
+	for _, hs := range sessions {
+		hs.close()
+		if err := ht.server.CloseSession(hs.session); err != nil {
+			log.Printf("Error closing session: %v", err)
+		}
+	}
+}
+
+// endStreams ends the SSE streams of every session
+func (ht *HTTPTransport) endStreams() {
+	ht.sessionsMutex.Lock()
+	defer ht.sessionsMutex.Unlock()
+	for _, hs := range ht.sessions {
+		hs.close()
+	}
+}
+
+// logRequest logs a request about to be processed, with its ID as the
+// client sent it. Notifications have no ID to log.
+func logRequest(req mcp.JSONRPCRequest) {
+	if req.IsNotification() {
+		log.Printf("Processing MCP notification: method=%s", req.Method)
+		return
+	}
+	id, err := json.Marshal(req.ID)
+	if err != nil {
+		id = []byte(fmt.Sprintf("%v", req.ID))
+	}
+	log.Printf("Processing MCP message: method=%s, id=%s", req.Method, id)
+}
+
+// newSessionID returns a random, unguessable session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// principalName returns the name of principal, or "" if it is nil
+func principalName(principal *Principal) string {
+	if principal == nil {
+		return ""
+	}
+	return principal.Name
+}
+
+// supportedVersion reports whether the server supports a protocol version
+func supportedVersion(version string) bool {
+	for _, supported := range mcp.SupportedProtocolVersions() {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
 // handleOAuthResource handles OAuth protected resource endpoint
 func (ht *HTTPTransport) handleOAuthResource(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
@@ -195,7 +726,7 @@ func (ht *HTTPTransport) handleOAuthResource(w http.ResponseWriter, r *http.Requ
 	response := map[string]interface{}{
 		"resource": "mcp-server",
 		"scopes":   []string{"mcp:read", "mcp:write"},
-		"auth":     false, // No authentication required
+		"auth":     ht.auth != nil, // Bearer tokens are only required when configured
 	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Error encoding OAuth response: %v", err)
@@ -246,6 +777,16 @@ func (ht *HTTPTransport) handleRoot(w http.ResponseWriter, r *http.Request) {
             <p>StreamableHttp endpoint for MCP Inspector (JSON over %s)</p>
         </div>
         
+        <div class="endpoint">
+            <h3><span class="method">GET</span> /mcp</h3>
+            <p>SSE stream of server messages for the session named by Mcp-Session-Id</p>
+        </div>
+        
+        <div class="endpoint">
+            <h3><span class="method">GET</span> /sse, <span class="method">POST</span> /messages</h3>
+            <p>HTTP+SSE transport for clients of protocol version 2024-11-05</p>
+        </div>
+        
         <div class="endpoint">
             <h3><span class="method">GET</span> /.well-known/oauth-protected-resource</h3>
             <p>OAuth resource info</p>
@@ -256,6 +797,7 @@ func (ht *HTTPTransport) handleRoot(w http.ResponseWriter, r *http.Request) {
             <li><strong>add_color</strong> - Add a color to your favorites list</li>
             <li><strong>get_colors</strong> - Get all favorite colors</li>
             <li><strong>remove_color</strong> - Remove a color from your favorites list</li>
+            <li><strong>import_colors</strong> - Add many colors at once, with progress</li>
             <li><strong>clear_colors</strong> - Clear all favorite colors</li>
         </ul>
     </div>
@@ -272,8 +814,9 @@ func (ht *HTTPTransport) handleRoot(w http.ResponseWriter, r *http.Request) {
 func corsHandler(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Mcp-Session-Id, MCP-Protocol-Version, Last-Event-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
 		if r.Method == "OPTIONS" {
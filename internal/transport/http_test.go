@@ -1,14 +1,33 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
 package transport
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"favorite-colors-mcp/internal/mcp"
+	"favorite-colors-mcp/internal/storage"
 )
 
 func TestHTTPTransport_HandleMCP(t *testing.T) {
@@ -181,10 +200,24 @@ func TestHTTPTransport_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestHTTPTransport_BodyTooLarge(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"pad":"` + strings.Repeat("x", maxBodyBytes) + `"}}`
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ht.handleMCP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", w.Code)
+	}
+}
+
 func TestHTTPTransport_MethodNotAllowed(t *testing.T) {
 	ht := NewHTTPTransport(":8080", false, "", "")
 
-	req := httptest.NewRequest("GET", "/mcp", nil)
+	req := httptest.NewRequest("PUT", "/mcp", nil)
 	w := httptest.NewRecorder()
 
 	ht.handleMCP(w, req)
@@ -208,3 +241,385 @@ func TestCORSHandler(t *testing.T) {
 		t.Errorf("Expected CORS origin *, got %s", w.Header().Get("Access-Control-Allow-Origin"))
 	}
 }
+
+func postToolCall(t *testing.T, ht *HTTPTransport, headers map[string]string, name string, args map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return postRPC(t, ht, headers, mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": name, "arguments": args},
+	})
+}
+
+// postRPC posts a JSON-RPC message to the MCP endpoint
+func postRPC(t *testing.T, ht *HTTPTransport, headers map[string]string, req mcp.JSONRPCRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+
+	httpReq := httptest.NewRequest("POST", "/mcp", bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		httpReq.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	ht.handleMCP(w, httpReq)
+	return w
+}
+
+// initializeSession starts a session, returning the headers that continue it
+func initializeSession(t *testing.T, ht *HTTPTransport, headers map[string]string) map[string]string {
+	t.Helper()
+	w := postRPC(t, ht, headers, mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "initialize",
+		Params:  map[string]interface{}{"protocolVersion": mcp.LatestProtocolVersion},
+	})
+	sessionID := w.Header().Get("Mcp-Session-Id")
+	if w.Code != http.StatusOK || sessionID == "" {
+		t.Fatalf("Expected a new session, got %d %q: %s", w.Code, sessionID, w.Body.String())
+	}
+
+	session := map[string]string{"Mcp-Session-Id": sessionID}
+	for key, value := range headers {
+		session[key] = value
+	}
+	postRPC(t, ht, session, mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	return session
+}
+
+func TestHTTPTransport_SessionNamespaces(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+
+	one := initializeSession(t, ht, nil)
+	two := initializeSession(t, ht, nil)
+	if one["Mcp-Session-Id"] == two["Mcp-Session-Id"] {
+		t.Fatal("Expected distinct session IDs")
+	}
+
+	postToolCall(t, ht, one, "add_color", map[string]interface{}{"color": "blue"})
+	w := postToolCall(t, ht, two, "get_colors", nil)
+
+	if strings.Contains(w.Body.String(), "blue") {
+		t.Errorf("Expected session two not to see session one's colors, got: %s", w.Body.String())
+	}
+
+	w = postToolCall(t, ht, one, "get_colors", nil)
+	if !strings.Contains(w.Body.String(), "blue") {
+		t.Errorf("Expected session one to see its colors, got: %s", w.Body.String())
+	}
+
+	// Requests outside of a session have no namespace to use
+	if w := postToolCall(t, ht, nil, "get_colors", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a session, got %d", w.Code)
+	}
+
+	// Session IDs are issued by the server, not chosen by clients
+	if w := postToolCall(t, ht, map[string]string{"Mcp-Session-Id": "made-up"}, "get_colors", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown session, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_DeleteSession(t *testing.T) {
+	backend := storage.NewBackend(func(string) (storage.Store, error) {
+		return storage.NewColorStorage(), nil
+	})
+	ht := NewHTTPTransportWithServer(mcp.NewServerWithStorage(backend), ":8080", false, "", "")
+
+	session := initializeSession(t, ht, nil)
+	postToolCall(t, ht, session, "add_color", map[string]interface{}{"color": "blue"})
+	// Anonymous favorites are kept out of the backend, which may persist them
+	if names, _ := backend.Namespaces(); len(names) != 0 {
+		t.Fatalf("Expected no namespace in the backend, got %q", names)
+	}
+
+	req := httptest.NewRequest("DELETE", "/mcp", nil)
+	req.Header.Set("Mcp-Session-Id", session["Mcp-Session-Id"])
+	w := httptest.NewRecorder()
+	ht.handleMCP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", w.Code)
+	}
+
+	if w := postToolCall(t, ht, session, "get_colors", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	ht.handleMCP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting twice, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_SessionExpiry(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	ht.idleTimeout = time.Hour
+
+	idle := initializeSession(t, ht, nil)
+	active := initializeSession(t, ht, nil)
+	postToolCall(t, ht, idle, "add_color", map[string]interface{}{"color": "blue"})
+	idleSession := sessionOf(t, ht, idle["Mcp-Session-Id"])
+
+	ht.sessionsMutex.Lock()
+	ht.sessions[idle["Mcp-Session-Id"]].lastUsed = time.Now().Add(-2 * time.Hour)
+	ht.sessionsMutex.Unlock()
+
+	ht.expireSessions()
+	if w := postToolCall(t, ht, idle, "get_colors", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired session, got %d", w.Code)
+	}
+	if !idleSession.Closed() {
+		t.Error("Expected the expired session to be closed")
+	}
+	if w := postToolCall(t, ht, active, "get_colors", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected the active session to remain, got %d", w.Code)
+	}
+
+	// Sessions with a request or stream in progress do not expire
+	ht.sessionsMutex.Lock()
+	hs := ht.sessions[active["Mcp-Session-Id"]]
+	ht.sessionsMutex.Unlock()
+	release := ht.use(hs)
+	ht.sessionsMutex.Lock()
+	hs.lastUsed = time.Now().Add(-2 * time.Hour)
+	ht.sessionsMutex.Unlock()
+	ht.expireSessions()
+	release()
+	if w := postToolCall(t, ht, active, "get_colors", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected a busy session to remain, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_SessionLimit(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	ht.maxSessions = 2
+
+	first := initializeSession(t, ht, nil)
+	initializeSession(t, ht, nil)
+
+	w := postRPC(t, ht, nil, mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "initialize",
+		Params:  map[string]interface{}{"protocolVersion": mcp.LatestProtocolVersion},
+	})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 past the session limit, got %d", w.Code)
+	}
+
+	// Idle sessions make room for new ones
+	ht.sessionsMutex.Lock()
+	ht.sessions[first["Mcp-Session-Id"]].lastUsed = time.Now().Add(-2 * ht.idleTimeout)
+	ht.sessionsMutex.Unlock()
+	initializeSession(t, ht, nil)
+}
+
+func TestHTTPTransport_Lifecycle(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	session := initializeSession(t, ht, nil)
+
+	w := postRPC(t, ht, session, mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "ping"})
+	if !strings.Contains(w.Body.String(), `"result":{}`) {
+		t.Errorf("Expected an empty ping result, got: %s", w.Body.String())
+	}
+
+	w = postRPC(t, ht, session, mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 3, Method: "initialize"})
+	if !strings.Contains(w.Body.String(), "already initialized") {
+		t.Errorf("Expected a second initialize to be rejected, got: %s", w.Body.String())
+	}
+
+	// Only initialize may come without a session
+	w = postRPC(t, ht, nil, mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a notification without a session, got %d", w.Code)
+	}
+
+	// Until notifications/initialized, the session only answers pings
+	w = postRPC(t, ht, nil, mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: map[string]interface{}{"protocolVersion": mcp.LatestProtocolVersion}})
+	session = map[string]string{"Mcp-Session-Id": w.Header().Get("Mcp-Session-Id")}
+	if w := postToolCall(t, ht, session, "get_colors", nil); !strings.Contains(w.Body.String(), "-32002") {
+		t.Errorf("Expected calls before notifications/initialized to fail, got: %s", w.Body.String())
+	}
+	if w := postRPC(t, ht, session, mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "ping"}); !strings.Contains(w.Body.String(), `"result":{}`) {
+		t.Errorf("Expected pings to be answered, got: %s", w.Body.String())
+	}
+
+	postRPC(t, ht, session, mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	if w := postToolCall(t, ht, session, "get_colors", nil); strings.Contains(w.Body.String(), "error") {
+		t.Errorf("Expected calls once initialized to succeed, got: %s", w.Body.String())
+	}
+}
+
+func TestHTTPTransport_Authentication(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	ht.SetAuthenticator(NewTokenAuthenticator(map[string]Principal{
+		"alice-token": {Name: "alice"},
+		"bob-token":   {Name: "bob"},
+		"root-token":  {Name: "root", Admin: true},
+	}))
+
+	w := postToolCall(t, ht, nil, "get_colors", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without a token, got %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Bearer") {
+		t.Errorf("Expected Bearer challenge, got %q", w.Header().Get("WWW-Authenticate"))
+	}
+
+	alice := map[string]string{"Authorization": "Bearer alice-token"}
+	root := map[string]string{"Authorization": "Bearer root-token"}
+
+	// The principal, not the session, selects the namespace
+	session := initializeSession(t, ht, alice)
+	postToolCall(t, ht, session, "add_color", map[string]interface{}{"color": "teal"})
+	if w := postToolCall(t, ht, initializeSession(t, ht, alice), "get_colors", nil); !strings.Contains(w.Body.String(), "teal") {
+		t.Errorf("Expected alice to see teal, got: %s", w.Body.String())
+	}
+
+	// Sessions cannot be taken over by another principal
+	stolen := map[string]string{"Authorization": "Bearer bob-token", "Mcp-Session-Id": session["Mcp-Session-Id"]}
+	if w := postToolCall(t, ht, stolen, "get_colors", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another principal's session, got %d", w.Code)
+	}
+
+	if w := postToolCall(t, ht, session, "list_namespaces", nil); !strings.Contains(w.Body.String(), "Tool not found") {
+		t.Errorf("Expected list_namespaces to be refused for alice, got: %s", w.Body.String())
+	}
+	if w := postToolCall(t, ht, initializeSession(t, ht, root), "list_namespaces", nil); !strings.Contains(w.Body.String(), "user:alice") {
+		t.Errorf("Expected root to list user:alice, got: %s", w.Body.String())
+	}
+}
+
+func TestHTTPTransport_ProtocolVersionHeader(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	session := initializeSession(t, ht, nil)
+
+	w := postToolCall(t, ht, session, "get_colors", nil)
+	if !strings.Contains(w.Body.String(), "structuredContent") {
+		t.Errorf("Expected structured content for %s, got: %s", mcp.LatestProtocolVersion, w.Body.String())
+	}
+
+	session["MCP-Protocol-Version"] = "1999-01-01"
+	w = postToolCall(t, ht, session, "get_colors", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported version, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_Notification(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	session := initializeSession(t, ht, nil)
+
+	w := postRPC(t, ht, session, mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]interface{}{"requestId": 1}})
+	if w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Errorf("Expected 202 without a body, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = postRPC(t, ht, session, mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 0, Method: "ping"})
+	if !strings.Contains(w.Body.String(), `"id":0`) {
+		t.Errorf("Expected id 0 to be echoed, got: %s", w.Body.String())
+	}
+}
+
+func TestHTTPTransport_ClientDisconnect(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	started := make(chan struct{})
+	ht.server.RegisterTool(mcp.Tool{Name: "block", InputSchema: mcp.ToolSchema{Type: "object"}},
+		func(ctx context.Context, args map[string]interface{}) (*mcp.ToolResult, error) {
+			close(started)
+			<-ctx.Done()
+			return &mcp.ToolResult{Text: "too late"}, nil
+		})
+	session := initializeSession(t, ht, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"block"}}`
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Mcp-Session-Id", session["Mcp-Session-Id"])
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ht.handleMCP(w, req)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the call to be cancelled with the request")
+	}
+	if strings.Contains(w.Body.String(), "too late") {
+		t.Errorf("Expected no response to the cancelled call, got %s", w.Body.String())
+	}
+}
+
+func TestHTTPTransport_Batch(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	session := initializeSession(t, ht, nil)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", session["Mcp-Session-Id"])
+		w := httptest.NewRecorder()
+		ht.handleMCP(w, req)
+		return w
+	}
+
+	w := post(`[
+		{"jsonrpc":"2.0","id":"add","method":"tools/call","params":{"name":"add_color","arguments":{"color":"blue"}}},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":2,"method":"ping"}
+	]`)
+	var responses []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &responses); err != nil {
+		t.Fatalf("Expected an array response, got %s", w.Body.String())
+	}
+	if len(responses) != 2 || responses[0]["id"] != "add" || responses[1]["id"] != float64(2) {
+		t.Errorf("Expected responses to add and 2 in order, got %v", responses)
+	}
+
+	if w := post(`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`); w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Errorf("Expected 202 for a batch of notifications, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(`[]`); !strings.Contains(w.Body.String(), `"code":-32600`) {
+		t.Errorf("Expected an invalid request error for an empty batch, got: %s", w.Body.String())
+	}
+	if w := post(`[{"jsonrpc":"2.0","id":1,"method":"ping"},`); !strings.Contains(w.Body.String(), `"code":-32700`) {
+		t.Errorf("Expected a parse error, got: %s", w.Body.String())
+	}
+
+	// A batch cannot start a session
+	delete(session, "Mcp-Session-Id")
+	if w := post(`[{"jsonrpc":"2.0","id":1,"method":"initialize"}]`); w.Header().Get("Mcp-Session-Id") != "" {
+		t.Error("Expected no session for a batched initialize")
+	}
+}
+
+func TestLogRequest(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	logRequest(mcp.JSONRPCRequest{JSONRPC: "2.0", ID: json.RawMessage(`"a"`), Method: "ping"})
+	logRequest(mcp.JSONRPCRequest{JSONRPC: "2.0", ID: 7, Method: "ping"})
+	logRequest(mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+
+	logged := out.String()
+	for _, want := range []string{`method=ping, id="a"`, `method=ping, id=7`, "notification: method=notifications/initialized\n"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("Expected %q in the log, got:\n%s", want, logged)
+		}
+	}
+	if strings.Contains(logged, "%!") {
+		t.Errorf("Expected IDs to be formatted, got:\n%s", logged)
+	}
+}
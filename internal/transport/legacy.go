@@ -0,0 +1,134 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+// handleLegacyStream serves the HTTP+SSE transport of protocol version
+// 2024-11-05. The GET stream opens a session that lasts as long as the
+// stream. Its first event, "endpoint", gives the URL to POST messages to;
+// every response and notification then arrives on the stream.
+func (ht *HTTPTransport) handleLegacyStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := ht.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, hs, err := ht.openSession(principal, true)
+	if errors.Is(err, errTooManySessions) {
+		http.Error(w, "Too many sessions", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer ht.endLegacySession(sessionID, hs)
+	release := ht.use(hs)
+	defer release()
+
+	endpoint := "/messages?sessionId=" + url.QueryEscape(sessionID)
+	hs.events.appendEvent(standaloneStream, "endpoint", []byte(endpoint))
+
+	log.Printf("HTTP+SSE session started: %s", sessionID)
+	serveEvents(w, hs.events, standaloneStream, 0, r.Context().Done())
+}
+
+// handleLegacyMessage accepts a message for an HTTP+SSE session. The reply
+// is sent on the session's stream, not in the response.
+func (ht *HTTPTransport) handleLegacyMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := ht.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		http.Error(w, "sessionId required", http.StatusBadRequest)
+		return
+	}
+	hs, ok := ht.findSession(w, sessionID, principal, true)
+	if !ok {
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+
+	msg, rpcErr := mcp.ParseMessage(body)
+	if rpcErr != nil {
+		log.Printf("Invalid JSON-RPC message: %s", rpcErr.Message)
+		http.Error(w, rpcErr.Message, http.StatusBadRequest)
+		return
+	}
+
+	for _, req := range msg.Requests {
+		logRequest(req)
+	}
+
+	// The reply outlives this request; the end of the session cancels it
+	ctx := context.WithoutCancel(r.Context())
+	release := ht.use(hs)
+	go func() {
+		defer release()
+		reply := msg.Reply(ht.server.HandleSessionMessage(ctx, hs.session, msg))
+		if reply == nil {
+			return
+		}
+		data, err := json.Marshal(reply)
+		if err != nil {
+			log.Printf("Response encoding error: %v", err)
+			return
+		}
+		hs.events.append(standaloneStream, data)
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// endLegacySession closes an HTTP+SSE session once its stream has ended
+func (ht *HTTPTransport) endLegacySession(sessionID string, hs *httpSession) {
+	ht.sessionsMutex.Lock()
+	delete(ht.sessions, sessionID)
+	ht.sessionsMutex.Unlock()
+
+	hs.close()
+	if err := ht.server.CloseSession(hs.session); err != nil {
+		log.Printf("Error closing session: %v", err)
+	}
+	log.Printf("HTTP+SSE session ended: %s", sessionID)
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPTransport_LegacySSE(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	srv := httptest.NewServer(ht.handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+	events := bufio.NewReader(resp.Body)
+
+	endpoint, err := readEvent(t, events)
+	if err != nil || !strings.HasPrefix(endpoint.data, "/messages?sessionId=") {
+		t.Fatalf("Expected the endpoint event, got %+v (%v)", endpoint, err)
+	}
+
+	post := func(body string) int {
+		t.Helper()
+		resp, err := http.Post(srv.URL+endpoint.data, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to post message: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`); code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", code)
+	}
+	event, err := readEvent(t, events)
+	if err != nil || !strings.Contains(event.data, `"protocolVersion":"2024-11-05"`) {
+		t.Fatalf("Expected the initialize response on the stream, got %+v (%v)", event, err)
+	}
+
+	post(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	post(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"add_color","arguments":{"color":"blue"}}}`)
+	event, err = readEvent(t, events)
+	if err != nil || !strings.Contains(event.data, `"id":2`) || !strings.Contains(event.data, "blue") {
+		t.Fatalf("Expected the tools/call response on the stream, got %+v (%v)", event, err)
+	}
+
+	if code := post(`{"jsonrpc":"2.0",`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid message, got %d", code)
+	}
+
+	// Legacy and Streamable HTTP sessions are not interchangeable
+	sessionID := strings.TrimPrefix(endpoint.data, "/messages?sessionId=")
+	if w := postToolCall(t, ht, map[string]string{"Mcp-Session-Id": sessionID}, "get_colors", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 using a legacy session over Streamable HTTP, got %d", w.Code)
+	}
+
+	// The session ends with its stream
+	resp.Body.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ht.server.Sessions()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the session to close with its stream")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := post(`{"jsonrpc":"2.0","id":3,"method":"ping"}`); code != http.StatusNotFound {
+		t.Errorf("Expected 404 after the stream ended, got %d", code)
+	}
+}
+
+func TestHTTPTransport_LegacyMessageErrors(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"no session", "POST", "/messages", http.StatusBadRequest},
+		{"unknown session", "POST", "/messages?sessionId=nope", http.StatusNotFound},
+		{"wrong method", "GET", "/messages?sessionId=nope", http.StatusMethodNotAllowed},
+		{"stream method", "POST", "/sse", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+			w := httptest.NewRecorder()
+			ht.handler().ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
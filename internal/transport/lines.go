@@ -0,0 +1,236 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+const (
+	// maxLineSize bounds a message read by a line connection; longer lines
+	// are answered with an error and skipped
+	maxLineSize = 4 << 20
+	// lineMaxInFlight bounds the requests of a line connection handled at
+	// once
+	lineMaxInFlight = 16
+)
+
+// errLineTooLong reports a line longer than maxLineSize
+var errLineTooLong = errors.New("line too long")
+
+// errServerBusy answers the requests of a connection that already has limit
+// requests in flight
+func errServerBusy(limit int) *mcp.JSONRPCError {
+	return &mcp.JSONRPCError{
+		Code:    -32000,
+		Message: "Server busy",
+		Data:    fmt.Sprintf("%d requests are already in flight", limit),
+	}
+}
+
+// lineConn carries the newline-delimited JSON-RPC messages of one session
+// over a byte stream, such as stdio or a socket connection
+type lineConn struct {
+	server  *mcp.Server
+	session *mcp.Session
+	out     io.Writer
+
+	// Messages are written to out one at a time by the writer goroutine,
+	// which runs while the connection is served
+	queue   chan []byte
+	stopped chan struct{}
+}
+
+// newLineConn returns a connection writing to out on behalf of session,
+// which it also uses to send the server's own messages
+func newLineConn(server *mcp.Server, session *mcp.Session, out io.Writer) *lineConn {
+	c := &lineConn{
+		server:  server,
+		session: session,
+		out:     out,
+		queue:   make(chan []byte),
+		stopped: make(chan struct{}),
+	}
+	session.SetSender(func(msg mcp.JSONRPCRequest) error {
+		return c.write(msg)
+	})
+	return c
+}
+
+// serve handles the messages read from in until it ends, then closes the
+// session. Requests are handled concurrently, up to lineMaxInFlight at a
+// time, so a slow tool call does not hold up the ones behind it;
+// notifications and initialize are handled in order as they are read,
+// which keeps notifications/initialized ahead of the requests that follow
+// it and lets notifications/cancelled find the requests read before it.
+// Requests in flight are cancelled when ctx is.
+func (c *lineConn) serve(ctx context.Context, in io.Reader) error {
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		c.writeLoop()
+	}()
+
+	var handlers sync.WaitGroup
+	inFlight := make(chan struct{}, lineMaxInFlight)
+	reader := bufio.NewReader(in)
+	var err error
+	for {
+		var line []byte
+		line, err = readLine(reader)
+		if err == errLineTooLong {
+			log.Printf("Error reading request: message exceeds %d bytes", maxLineSize)
+			c.writeOrLog(mcp.JSONRPCResponse{JSONRPC: "2.0", Error: &mcp.JSONRPCError{
+				Code:    -32600,
+				Message: "Invalid Request",
+				Data:    fmt.Sprintf("message exceeds %d bytes", maxLineSize),
+			}})
+			continue
+		}
+		if err != nil {
+			break
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		msg, rpcErr := mcp.ParseMessage(line)
+		if rpcErr != nil {
+			log.Printf("Error parsing request: %s", rpcErr.Message)
+			c.writeOrLog(mcp.JSONRPCResponse{JSONRPC: "2.0", Error: rpcErr})
+			continue
+		}
+		// initialize is handled in order too, so that the session is
+		// initializing when notifications/initialized is read
+		if !msg.NeedsReply() || msg.Method() == "initialize" {
+			if reply := msg.Reply(c.server.HandleSessionMessage(ctx, c.session, msg)); reply != nil {
+				c.writeOrLog(reply)
+			}
+			continue
+		}
+
+		// Past the limit, requests are refused rather than left to hold up
+		// the notifications read behind them
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			c.writeOrLog(msg.Refuse(errServerBusy(lineMaxInFlight)))
+			continue
+		}
+
+		// The requests are in flight from here, so a cancellation read
+		// next reaches them even before their handler runs
+		handle := c.server.StartSessionMessage(ctx, c.session, msg)
+		handlers.Add(1)
+		go func() {
+			defer func() {
+				<-inFlight
+				handlers.Done()
+			}()
+			if reply := msg.Reply(handle()); reply != nil {
+				c.writeOrLog(reply)
+			}
+		}()
+	}
+
+	// The client hung up; finish what it asked for, then release the
+	// session whatever the reason
+	handlers.Wait()
+	if closeErr := c.server.CloseSession(c.session); closeErr != nil {
+		log.Printf("Error closing session: %v", closeErr)
+	}
+	close(c.stopped)
+	<-writerDone
+
+	if err != io.EOF {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+	return nil
+}
+
+// readLine returns the next line of r. A line longer than maxLineSize is
+// consumed and reported as errLineTooLong, and a last line may lack its
+// newline.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !tooLong && len(line)+len(chunk) <= maxLineSize {
+			line = append(line, chunk...)
+		} else {
+			tooLong, line = true, nil
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && (len(line) > 0 || tooLong):
+			// The last line, which the next call reports the end of
+		case err != nil:
+			return nil, err
+		}
+		if tooLong {
+			return nil, errLineTooLong
+		}
+		return line, nil
+	}
+}
+
+// writeLoop writes queued messages to out until the connection stops
+func (c *lineConn) writeLoop() {
+	for {
+		select {
+		case data := <-c.queue:
+			if _, err := c.out.Write(data); err != nil {
+				log.Printf("Error writing message: %v", err)
+			}
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+// write queues a message to the client as one line
+func (c *lineConn) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.queue <- append(data, '\n'):
+		return nil
+	case <-c.stopped:
+		return mcp.ErrNotConnected
+	}
+}
+
+// writeOrLog writes a reply, logging rather than returning failures
+func (c *lineConn) writeOrLog(msg interface{}) {
+	if err := c.write(msg); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
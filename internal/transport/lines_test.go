@@ -0,0 +1,238 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+// lineClient talks to a line connection through pipes
+type lineClient struct {
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	served chan error
+}
+
+func serveLineConn(t *testing.T, server *mcp.Server) *lineClient {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	c := &lineClient{in: inWriter, out: bufio.NewReader(outReader), served: make(chan error, 1)}
+
+	conn := newLineConn(server, server.OpenSession(&mcp.Session{Admin: true}), outWriter)
+	go func() { c.served <- conn.serve(context.Background(), inReader) }()
+	t.Cleanup(func() {
+		inWriter.Close()
+		outReader.Close()
+	})
+	return c
+}
+
+func (c *lineClient) send(t *testing.T, line string) {
+	t.Helper()
+	if _, err := io.WriteString(c.in, line+"\n"); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+}
+
+func (c *lineClient) receive(t *testing.T) map[string]interface{} {
+	t.Helper()
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := c.out.ReadString('\n')
+		lines <- line
+	}()
+
+	select {
+	case line := <-lines:
+		var message map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			t.Fatalf("Expected a JSON line, got %q", line)
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a message")
+		return nil
+	}
+}
+
+func TestLineConn_Concurrency(t *testing.T) {
+	server := mcp.NewServer()
+	release := make(chan struct{})
+	server.RegisterTool(mcp.Tool{Name: "block", InputSchema: mcp.ToolSchema{Type: "object"}},
+		func(ctx context.Context, args map[string]interface{}) (*mcp.ToolResult, error) {
+			<-release
+			return &mcp.ToolResult{Text: "released"}, nil
+		})
+	c := serveLineConn(t, server)
+
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	c.receive(t)
+	// Requests right behind notifications/initialized see the session ready
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"block"}}`)
+
+	// A slow call does not hold up the requests behind it
+	c.send(t, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if message := c.receive(t); message["id"] != float64(3) {
+		t.Fatalf("Expected the ping answered first, got %v", message)
+	}
+	close(release)
+	if message := c.receive(t); message["id"] != float64(2) || !strings.Contains(mustJSON(t, message), "released") {
+		t.Errorf("Expected the tool result, got %v", message)
+	}
+
+	c.in.Close()
+	select {
+	case err := <-c.served:
+		if err != nil {
+			t.Errorf("Expected the end of input to stop cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected serve to return at the end of input")
+	}
+}
+
+func TestLineConn_LongLines(t *testing.T) {
+	c := serveLineConn(t, mcp.NewServer())
+
+	// Lines past bufio.Scanner's 64KB default are read whole
+	colors := strings.Repeat(`"red",`, 20000)
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"colors":[`+colors+`"blue"]}}`)
+	if message := c.receive(t); message["id"] != float64(1) || message["error"] != nil {
+		t.Errorf("Expected a long request answered, got %v", message)
+	}
+
+	// Lines past the limit are refused without ending the connection
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping","params":{"padding":"`+strings.Repeat("x", maxLineSize)+`"}}`)
+	message := c.receive(t)
+	if rpcErr, ok := message["error"].(map[string]interface{}); !ok || rpcErr["code"] != float64(-32600) {
+		t.Errorf("Expected an oversized request refused, got %v", message)
+	}
+	c.send(t, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if message := c.receive(t); message["id"] != float64(3) {
+		t.Errorf("Expected the connection to keep serving, got %v", message)
+	}
+}
+
+func TestLineConn_Cancellation(t *testing.T) {
+	server := mcp.NewServer()
+	cancelled := make(chan struct{})
+	server.RegisterTool(mcp.Tool{Name: "block", InputSchema: mcp.ToolSchema{Type: "object"}},
+		func(ctx context.Context, args map[string]interface{}) (*mcp.ToolResult, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return &mcp.ToolResult{Text: "too late"}, nil
+		})
+	c := serveLineConn(t, server)
+
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	c.receive(t)
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	// The cancellation is read right behind the call, likely before its
+	// handler runs, and must reach it all the same
+	c.send(t, `{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"block"}}`+"\n"+
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow"}}`)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the call to be cancelled")
+	}
+
+	// The cancelled call is not answered, so the next reply is the ping's
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if message := c.receive(t); message["id"] != float64(2) {
+		t.Errorf("Expected only the ping answered, got %v", message)
+	}
+}
+
+func TestLineConn_ResourceUpdates(t *testing.T) {
+	server := mcp.NewServer()
+	c := serveLineConn(t, server)
+
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	c.receive(t)
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"colors://favorites"}}`)
+	c.receive(t)
+
+	// A change by another client of the same favorites
+	server.HandleRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": "add_color", "arguments": map[string]interface{}{"color": "red"}},
+	})
+	if message := c.receive(t); message["method"] != "notifications/resources/updated" {
+		t.Errorf("Expected the update notification, got %v", message)
+	}
+}
+
+func TestLineConn_PipelinedInitialization(t *testing.T) {
+	c := serveLineConn(t, mcp.NewServer())
+
+	// A client may send the whole handshake without waiting for answers
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`+"\n"+
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n"+
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if message := c.receive(t); message["id"] != float64(1) || message["error"] != nil {
+		t.Fatalf("Expected initialize answered first, got %v", message)
+	}
+	if message := c.receive(t); message["id"] != float64(2) || message["error"] != nil {
+		t.Errorf("Expected tools/list answered, got %v", message)
+	}
+}
+
+func TestLineConn_InFlightLimit(t *testing.T) {
+	server := mcp.NewServer()
+	cancelled := make(chan struct{}, lineMaxInFlight)
+	server.RegisterTool(mcp.Tool{Name: "block", InputSchema: mcp.ToolSchema{Type: "object"}},
+		func(ctx context.Context, args map[string]interface{}) (*mcp.ToolResult, error) {
+			<-ctx.Done()
+			cancelled <- struct{}{}
+			return nil, ctx.Err()
+		})
+	c := serveLineConn(t, server)
+	c.send(t, `{"jsonrpc":"2.0","id":"init","method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	c.receive(t)
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	for i := 0; i < lineMaxInFlight; i++ {
+		c.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"block"}}`, i))
+	}
+
+	// Requests past the limit are refused at once, and notifications still
+	// get through
+	c.send(t, `{"jsonrpc":"2.0","id":"extra","method":"ping"}`)
+	message := c.receive(t)
+	if rpcErr, ok := message["error"].(map[string]interface{}); message["id"] != "extra" || !ok || rpcErr["code"] != float64(-32000) {
+		t.Fatalf("Expected the extra request refused, got %v", message)
+	}
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":0}}`)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the cancellation to reach a blocked call")
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxBufferedEvents bounds the events a session keeps for resumption
+	maxBufferedEvents = 256
+	// sseKeepAlive is how often idle streams get a comment, so that proxies
+	// do not time them out
+	sseKeepAlive = 25 * time.Second
+	// standaloneStream is the stream of GET requests, which carries the
+	// messages the server sends on its own
+	standaloneStream = 0
+)
+
+// sseEvent is a message on an SSE stream. An event without data ends its
+// stream and is not sent.
+type sseEvent struct {
+	id     uint64
+	stream uint64
+	name   string // event type, "" for the default message type
+	data   []byte
+}
+
+// eventLog orders the SSE events of a session across its streams and keeps
+// the latest ones, so that clients can resume a broken stream by sending the
+// ID of the last event they received as Last-Event-ID
+type eventLog struct {
+	mutex   sync.Mutex
+	events  []sseEvent
+	lastID  uint64
+	streams uint64        // last stream opened
+	changed chan struct{} // closed and replaced when events are added
+	closed  bool
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{changed: make(chan struct{})}
+}
+
+// openStream allocates a stream, such as for the response to a POST
+func (l *eventLog) openStream() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.streams++
+	return l.streams
+}
+
+// append adds a message with data to stream
+func (l *eventLog) append(stream uint64, data []byte) {
+	l.appendEvent(stream, "", data)
+}
+
+// appendEvent adds an event of type name with data to stream
+func (l *eventLog) appendEvent(stream uint64, name string, data []byte) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return
+	}
+
+	l.lastID++
+	l.events = append(l.events, sseEvent{id: l.lastID, stream: stream, name: name, data: data})
+	if len(l.events) > maxBufferedEvents {
+		l.events = append(l.events[:0], l.events[len(l.events)-maxBufferedEvents:]...)
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// finish ends stream once its events have been sent
+func (l *eventLog) finish(stream uint64) {
+	l.append(stream, nil)
+}
+
+// close ends every stream, as when the session is deleted
+func (l *eventLog) close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.changed)
+	}
+}
+
+// last returns the ID of the latest event
+func (l *eventLog) last() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.lastID
+}
+
+// streamOf returns the stream of an event that is still buffered
+func (l *eventLog) streamOf(id uint64) (uint64, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, event := range l.events {
+		if event.id == id {
+			return event.stream, true
+		}
+	}
+	return 0, false
+}
+
+// since returns the buffered events of stream after the event with ID
+// after, whether the stream has ended, and a channel closed when there may
+// be more
+func (l *eventLog) since(stream, after uint64) ([]sseEvent, bool, <-chan struct{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var events []sseEvent
+	for _, event := range l.events {
+		if event.id <= after || event.stream != stream {
+			continue
+		}
+		if event.data == nil {
+			return events, true, nil
+		}
+		events = append(events, event)
+	}
+	return events, l.closed, l.changed
+}
+
+// serveEvents writes the events of stream after the event with ID after
+// as they come, until the stream ends or done is closed
+func serveEvents(w http.ResponseWriter, events *eventLog, stream, after uint64, done <-chan struct{}) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	_ = rc.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		pending, ended, changed := events.since(stream, after)
+		for _, event := range pending {
+			if event.name != "" {
+				if _, err := fmt.Fprintf(w, "event: %s\n", event.name); err != nil {
+					return
+				}
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.id, event.data); err != nil {
+				return
+			}
+			after = event.id
+		}
+		if len(pending) > 0 {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+		if ended {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-done:
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// acceptsEventStream reports whether a request accepts SSE responses
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == "text/event-stream" {
+				return true
+			}
+		}
+	}
+	return false
+}
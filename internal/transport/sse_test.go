@@ -0,0 +1,323 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+type testEvent struct {
+	id   string
+	data string
+}
+
+// readEvent reads the next event of an SSE stream, skipping comments
+func readEvent(t *testing.T, r *bufio.Reader) (testEvent, error) {
+	t.Helper()
+	var event testEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return event, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.data != "":
+			return event, nil
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// openStream opens the GET stream of a session
+func openStream(t *testing.T, url, sessionID, lastEventID string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return resp
+}
+
+// sessionOf returns the MCP session behind an Mcp-Session-Id
+func sessionOf(t *testing.T, ht *HTTPTransport, sessionID string) *mcp.Session {
+	t.Helper()
+	ht.sessionsMutex.Lock()
+	defer ht.sessionsMutex.Unlock()
+	hs, ok := ht.sessions[sessionID]
+	if !ok {
+		t.Fatalf("Unknown session %q", sessionID)
+	}
+	return hs.session
+}
+
+func TestHTTPTransport_PostEventStream(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	sessionID := initializeSession(t, ht, nil)["Mcp-Session-Id"]
+
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"add_color","arguments":{"color":"blue"}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	w := httptest.NewRecorder()
+	ht.handleMCP(w, req)
+
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", w.Header().Get("Content-Type"))
+	}
+	event, err := readEvent(t, bufio.NewReader(w.Body))
+	if err != nil {
+		t.Fatalf("Failed to read event: %v", err)
+	}
+	var response mcp.JSONRPCResponse
+	if err := json.Unmarshal([]byte(event.data), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.ID != float64(7) || response.Error != nil || event.id == "" {
+		t.Errorf("Expected the response to request 7 with an event ID, got %+v (id %q)", response, event.id)
+	}
+
+	// Notifications are still only acknowledged
+	req = httptest.NewRequest("POST", "/mcp", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`))
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	w = httptest.NewRecorder()
+	ht.handleMCP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected 202, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_ProgressOnStream(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	sessionID := initializeSession(t, ht, nil)["Mcp-Session-Id"]
+
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"import_colors","arguments":{"colors":["red","blue"]},"_meta":{"progressToken":"p"}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	w := httptest.NewRecorder()
+	ht.handleMCP(w, req)
+
+	// Progress comes on the request's stream, ahead of the response
+	body := bufio.NewReader(w.Body)
+	for i := 1; i <= 2; i++ {
+		event, err := readEvent(t, body)
+		if err != nil {
+			t.Fatalf("Failed to read progress: %v", err)
+		}
+		var notification mcp.JSONRPCRequest
+		json.Unmarshal([]byte(event.data), &notification)
+		params, _ := notification.Params.(map[string]interface{})
+		if notification.Method != "notifications/progress" || params["progressToken"] != "p" || params["progress"] != float64(i) {
+			t.Errorf("Expected progress %d, got %s", i, event.data)
+		}
+	}
+	event, err := readEvent(t, body)
+	if err != nil || !strings.Contains(event.data, `"result"`) {
+		t.Errorf("Expected the response last, got %q (%v)", event.data, err)
+	}
+}
+
+func TestHTTPTransport_GetStream(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	srv := httptest.NewServer(http.HandlerFunc(ht.handleMCP))
+	defer srv.Close()
+
+	sessionID := initializeSession(t, ht, nil)["Mcp-Session-Id"]
+	session := sessionOf(t, ht, sessionID)
+
+	resp := openStream(t, srv.URL, sessionID, "")
+	events := bufio.NewReader(resp.Body)
+
+	// Messages are buffered until a stream takes them
+	for _, color := range []string{"red", "green"} {
+		if err := session.Notify("notifications/test", map[string]interface{}{"color": color}); err != nil {
+			t.Fatalf("Failed to notify: %v", err)
+		}
+	}
+	first, err := readEvent(t, events)
+	if err != nil || !strings.Contains(first.data, `"method":"notifications/test"`) || !strings.Contains(first.data, "red") {
+		t.Fatalf("Expected the red notification, got %+v (%v)", first, err)
+	}
+	if _, err := readEvent(t, events); err != nil {
+		t.Fatalf("Failed to read the green notification: %v", err)
+	}
+	resp.Body.Close()
+
+	// A new stream resumes after the last event the client saw
+	if err := session.Notify("notifications/test", map[string]interface{}{"color": "blue"}); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	resp = openStream(t, srv.URL, sessionID, first.id)
+	events = bufio.NewReader(resp.Body)
+	for _, color := range []string{"green", "blue"} {
+		event, err := readEvent(t, events)
+		if err != nil || !strings.Contains(event.data, color) {
+			t.Fatalf("Expected the %s notification to be replayed, got %+v (%v)", color, event, err)
+		}
+	}
+
+	// Deleting the session ends its stream
+	req, _ := http.NewRequest("DELETE", srv.URL, nil)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	if del, err := http.DefaultClient.Do(req); err != nil || del.StatusCode != http.StatusNoContent {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := readEvent(t, events)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Errorf("Expected the stream to end, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the stream to end when the session was deleted")
+	}
+	resp.Body.Close()
+}
+
+func TestHTTPTransport_GetStreamErrors(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	sessionID := initializeSession(t, ht, nil)["Mcp-Session-Id"]
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"no session", map[string]string{"Accept": "text/event-stream"}, http.StatusBadRequest},
+		{"unknown session", map[string]string{"Accept": "text/event-stream", "Mcp-Session-Id": "nope"}, http.StatusNotFound},
+		{"not accepted", map[string]string{"Accept": "application/json", "Mcp-Session-Id": sessionID}, http.StatusNotAcceptable},
+		{"bad event ID", map[string]string{"Accept": "text/event-stream", "Mcp-Session-Id": sessionID, "Last-Event-ID": "x"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/mcp", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			ht.handleMCP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	events := newEventLog()
+	post := events.openStream()
+
+	events.append(standaloneStream, []byte("a"))
+	events.append(post, []byte("b"))
+	events.finish(post)
+	events.append(standaloneStream, []byte("c"))
+
+	pending, ended, _ := events.since(post, 0)
+	if len(pending) != 1 || string(pending[0].data) != "b" || !ended {
+		t.Errorf("Expected b then the end of the POST stream, got %v (ended %v)", pending, ended)
+	}
+	pending, ended, _ = events.since(standaloneStream, 1)
+	if len(pending) != 1 || string(pending[0].data) != "c" || ended {
+		t.Errorf("Expected c on the open GET stream, got %v (ended %v)", pending, ended)
+	}
+	if stream, ok := events.streamOf(pending[0].id); !ok || stream != standaloneStream {
+		t.Errorf("Expected event %d on the GET stream, got %d", pending[0].id, stream)
+	}
+
+	for i := 0; i < maxBufferedEvents; i++ {
+		events.append(standaloneStream, []byte(strconv.Itoa(i)))
+	}
+	if _, ok := events.streamOf(1); ok {
+		t.Error("Expected the oldest events to be dropped")
+	}
+	if pending, _, _ := events.since(standaloneStream, 0); len(pending) != maxBufferedEvents {
+		t.Errorf("Expected %d buffered events, got %d", maxBufferedEvents, len(pending))
+	}
+
+	events.close()
+	if _, ended, _ := events.since(standaloneStream, events.last()); !ended {
+		t.Error("Expected closing to end every stream")
+	}
+}
+
+func TestHTTPTransport_ResourceUpdatesOnStream(t *testing.T) {
+	ht := NewHTTPTransport(":8080", false, "", "")
+	ht.SetAuthenticator(NewTokenAuthenticator(map[string]Principal{"alice-token": {Name: "alice"}}))
+	srv := httptest.NewServer(http.HandlerFunc(ht.handleMCP))
+	defer srv.Close()
+
+	alice := map[string]string{"Authorization": "Bearer alice-token"}
+	dashboard := initializeSession(t, ht, alice)
+	postRPC(t, ht, dashboard, mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "resources/subscribe",
+		Params:  map[string]interface{}{"uri": "colors://favorites"},
+	})
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	for key, value := range dashboard {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Another of alice's sessions changes her favorites
+	editor := initializeSession(t, ht, alice)
+	postToolCall(t, ht, editor, "add_color", map[string]interface{}{"color": "red"})
+
+	event, err := readEvent(t, bufio.NewReader(resp.Body))
+	if err != nil || !strings.Contains(event.data, `"method":"notifications/resources/updated"`) || !strings.Contains(event.data, `"uri":"colors://favorites"`) {
+		t.Errorf("Expected the update on the stream, got %+v (%v)", event, err)
+	}
+}
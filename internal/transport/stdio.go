@@ -15,9 +15,7 @@
 package transport
 
 import (
-	"bufio"
-	"encoding/json"
-	"fmt"
+	"context"
 	"log"
 	"os"
 
@@ -27,47 +25,29 @@ import (
 // StdioTransport handles stdio-based communication
 type StdioTransport struct {
 	server *mcp.Server
+	conn   *lineConn
 }
 
 // NewStdioTransport creates a new stdio transport
 func NewStdioTransport() *StdioTransport {
+	return NewStdioTransportWithServer(mcp.NewServer())
+}
+
+// NewStdioTransportWithServer creates a new stdio transport serving the given server
+func NewStdioTransportWithServer(server *mcp.Server) *StdioTransport {
+	// The client is whoever started the process, which already has full
+	// access to the data directory.
+	session := server.OpenSession(&mcp.Session{Admin: true})
 	return &StdioTransport{
-		server: mcp.NewServer(),
+		server: server,
+		conn:   newLineConn(server, session, os.Stdout),
 	}
 }
 
 // Run starts the stdio transport server
 func (st *StdioTransport) Run() error {
 	log.Println("Favorite Colors MCP Server starting (stdio transport)...")
-	log.Println("Available tools: add_color, get_colors, remove_color, clear_colors")
-
-	// Main server loop
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-
-		var req mcp.JSONRPCRequest
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			log.Printf("Error parsing request: %v", err)
-			continue
-		}
-
-		response := st.server.HandleRequest(req)
-		responseJSON, err := json.Marshal(response)
-		if err != nil {
-			log.Printf("Error marshaling response: %v", err)
-			continue
-		}
-
-		fmt.Println(string(responseJSON))
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading input: %w", err)
-	}
+	log.Println("Available tools: add_color, import_colors, get_colors, remove_color, clear_colors")
 
-	return nil
+	return st.conn.serve(context.Background(), os.Stdin)
 }
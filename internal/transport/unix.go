@@ -0,0 +1,283 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+// Protocols spoken on a Unix socket
+const (
+	// SocketProtocolLines is newline-delimited JSON-RPC, as on stdio
+	SocketProtocolLines = "lines"
+	// SocketProtocolHTTP is the HTTP transport
+	SocketProtocolHTTP = "http"
+)
+
+// UnixTransport serves MCP on a Unix domain socket. Clients are identified
+// by the user ID of their process (SO_PEERCRED), which selects their
+// favorites namespace: the server's own user is an admin, like the stdio
+// client, and every other user gets a namespace named after them.
+type UnixTransport struct {
+	server   *mcp.Server
+	path     string
+	mode     os.FileMode
+	protocol string
+
+	allowedUIDs map[uint32]bool // nil admits every user
+
+	connsMutex sync.Mutex
+	conns      map[net.Conn]struct{}
+	httpServer *http.Server // serving the socket, with the http protocol
+}
+
+// NewUnixTransport creates a new Unix socket transport
+func NewUnixTransport(path string, mode os.FileMode, protocol string) (*UnixTransport, error) {
+	return NewUnixTransportWithServer(mcp.NewServer(), path, mode, protocol)
+}
+
+// NewUnixTransportWithServer creates a new Unix socket transport serving the
+// given server. The socket at path is created with permissions mode and
+// speaks protocol, SocketProtocolLines or SocketProtocolHTTP. It fails on
+// platforms other than Linux, where clients cannot be identified.
+func NewUnixTransportWithServer(server *mcp.Server, path string, mode os.FileMode, protocol string) (*UnixTransport, error) {
+	if err := checkPlatform(); err != nil {
+		return nil, err
+	}
+	if protocol != SocketProtocolLines && protocol != SocketProtocolHTTP {
+		return nil, fmt.Errorf("unknown socket protocol %q", protocol)
+	}
+	return &UnixTransport{
+		server:   server,
+		path:     path,
+		mode:     mode,
+		protocol: protocol,
+		conns:    make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// AllowUIDs only admits clients running as one of uids
+func (ut *UnixTransport) AllowUIDs(uids ...uint32) {
+	ut.allowedUIDs = make(map[uint32]bool, len(uids))
+	for _, uid := range uids {
+		ut.allowedUIDs[uid] = true
+	}
+}
+
+// Run starts the Unix socket transport server
+func (ut *UnixTransport) Run() error {
+	listener, err := ut.Listen()
+	if err != nil {
+		return err
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	served := make(chan error, 1)
+	go func() {
+		log.Printf("Favorite Colors MCP Server listening on unix:%s (%s, mode %04o)", ut.path, ut.protocol, ut.mode)
+		log.Println("Available tools: add_color, import_colors, get_colors, remove_color, clear_colors")
+		served <- ut.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-quit:
+	}
+
+	log.Println()
+	log.Println("Shutting down server...")
+	if err := listener.Close(); err != nil {
+		log.Printf("Error closing listener: %v", err)
+	}
+	ut.closeConns()
+	if err := <-served; err != nil {
+		return err
+	}
+	log.Println("Server shutdown gracefully")
+	return nil
+}
+
+// Listen creates the socket with the transport's permissions, replacing a
+// stale socket left by a previous run
+func (ut *UnixTransport) Listen() (net.Listener, error) {
+	if info, err := os.Lstat(ut.path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", ut.path)
+		}
+		if conn, err := net.Dial("unix", ut.path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", ut.path)
+		}
+		if err := os.Remove(ut.path); err != nil {
+			return nil, fmt.Errorf("error removing stale socket: %w", err)
+		}
+	}
+
+	listener, err := listenUnix(ut.path, ut.mode)
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %w", ut.path, err)
+	}
+	return listener, nil
+}
+
+// Serve accepts connections on listener until it is closed, then removes
+// the socket
+func (ut *UnixTransport) Serve(listener net.Listener) error {
+	defer os.Remove(ut.path)
+	peers := &peerListener{Listener: listener, transport: ut}
+
+	if ut.protocol == SocketProtocolHTTP {
+		ht := NewHTTPTransportWithServer(ut.server, "", false, "", "")
+		httpServer := &http.Server{
+			Handler:           ht.handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+			ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+				return context.WithValue(ctx, connPrincipalKey{}, conn.(*peerConn).principal)
+			},
+		}
+		ut.connsMutex.Lock()
+		ut.httpServer = httpServer
+		ut.connsMutex.Unlock()
+
+		err := httpServer.Serve(peers)
+		ht.closeSessions()
+		if errors.Is(err, net.ErrClosed) || err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	}
+
+	// Requests still in flight when the server stops are cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for {
+		conn, err := peers.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go ut.serveLines(ctx, conn.(*peerConn))
+	}
+}
+
+// serveLines serves a connection speaking newline-delimited JSON-RPC
+func (ut *UnixTransport) serveLines(ctx context.Context, conn *peerConn) {
+	ut.connsMutex.Lock()
+	ut.conns[conn] = struct{}{}
+	ut.connsMutex.Unlock()
+	defer func() {
+		ut.connsMutex.Lock()
+		delete(ut.conns, conn)
+		ut.connsMutex.Unlock()
+		_ = conn.Close()
+	}()
+
+	session := ut.server.OpenSession(&mcp.Session{
+		Namespace: conn.principal.namespace(),
+		Admin:     conn.principal.Admin,
+	})
+	if err := newLineConn(ut.server, session, conn).serve(ctx, conn); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Printf("Error serving %s: %v", conn.principal.Name, err)
+	}
+}
+
+// closeConns closes every connection, as when the server shuts down
+func (ut *UnixTransport) closeConns() {
+	ut.connsMutex.Lock()
+	defer ut.connsMutex.Unlock()
+	if ut.httpServer != nil {
+		_ = ut.httpServer.Close()
+	}
+	for conn := range ut.conns {
+		_ = conn.Close()
+	}
+}
+
+// principalFor returns the principal of the user a client runs as, or false
+// if the user is not admitted
+func (ut *UnixTransport) principalFor(uid uint32) (*Principal, bool) {
+	if ut.allowedUIDs != nil && !ut.allowedUIDs[uid] {
+		return nil, false
+	}
+
+	name := strconv.FormatUint(uint64(uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	return &Principal{
+		Name:      name,
+		Admin:     uid == uint32(os.Getuid()),
+		Namespace: mcp.UIDNamespace(uid),
+	}, true
+}
+
+// peerListener admits connections whose peer credentials identify an
+// allowed user, and closes the others
+type peerListener struct {
+	net.Listener
+	transport *UnixTransport
+}
+
+// peerConn is a connection from an identified user
+type peerConn struct {
+	net.Conn
+	principal *Principal
+}
+
+// connPrincipalKey is the context key of the principal a connection was
+// authenticated as by the transport, rather than by a bearer token
+type connPrincipalKey struct{}
+
+func (l *peerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		uid, err := peerUID(conn)
+		if err != nil {
+			log.Printf("Rejecting connection: %v", err)
+			_ = conn.Close()
+			continue
+		}
+		principal, ok := l.transport.principalFor(uid)
+		if !ok {
+			log.Printf("Rejecting connection from uid %d", uid)
+			_ = conn.Close()
+			continue
+		}
+		return &peerConn{Conn: conn, principal: principal}, nil
+	}
+}
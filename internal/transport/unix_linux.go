@@ -0,0 +1,65 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package transport
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// checkPlatform reports whether the Unix socket transport can run here. It
+// can on Linux, where clients are identified through SO_PEERCRED.
+func checkPlatform() error {
+	return nil
+}
+
+// listenUnix creates a socket at path with permissions mode. The socket is
+// created under a umask that leaves only those permissions, so it is never
+// reachable with looser ones. The umask is process-wide, which is why the
+// socket is created before the server starts handling requests.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	old := syscall.Umask(int(^mode.Perm() & os.ModePerm))
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
+
+// peerUID returns the user ID of the process at the other end of a Unix
+// socket connection, as recorded by the kernel when it connected
+func peerUID(conn net.Conn) (uint32, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, fmt.Errorf("not a Unix socket connection: %T", conn)
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("error reading peer credentials: %w", credErr)
+	}
+	return cred.Uid, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package transport
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// errUnsupportedPlatform is returned on platforms where the Unix socket
+// transport cannot identify its clients
+var errUnsupportedPlatform = errors.New("the unix transport identifies clients with SO_PEERCRED, which is only supported on Linux")
+
+// checkPlatform reports whether the Unix socket transport can run here
+func checkPlatform() error {
+	return errUnsupportedPlatform
+}
+
+// listenUnix is only implemented on Linux
+func listenUnix(string, os.FileMode) (net.Listener, error) {
+	return nil, errUnsupportedPlatform
+}
+
+// peerUID is only implemented on Linux, through SO_PEERCRED
+func peerUID(net.Conn) (uint32, error) {
+	return 0, errUnsupportedPlatform
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+// serveUnix serves the transport on a socket in a temporary directory,
+// returning the socket's path
+func serveUnix(t *testing.T, ut *UnixTransport) string {
+	t.Helper()
+	listener, err := ut.Listen()
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- ut.Serve(listener) }()
+	t.Cleanup(func() {
+		listener.Close()
+		ut.closeConns()
+		if err := <-served; err != nil {
+			t.Errorf("Serve failed: %v", err)
+		}
+	})
+	return ut.path
+}
+
+func TestUnixTransport_Lines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	ut, err := NewUnixTransport(path, 0o660, SocketProtocolLines)
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	serveUnix(t, ut)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the socket to exist: %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o660 {
+		t.Errorf("Expected a socket with mode 0660, got %v", info.Mode())
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	roundTrip := func(line string) map[string]interface{} {
+		t.Helper()
+		if _, err := conn.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		reply, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+		var message map[string]interface{}
+		if err := json.Unmarshal(reply, &message); err != nil {
+			t.Fatalf("Expected a JSON line, got %q", reply)
+		}
+		return message
+	}
+
+	if message := roundTrip(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`); message["result"] == nil {
+		t.Fatalf("Expected the initialize result, got %v", message)
+	}
+	conn.Write([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n"))
+	if message := roundTrip(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"add_color","arguments":{"color":"teal"}}}`); !strings.Contains(mustJSON(t, message), "teal") {
+		t.Errorf("Expected the add_color result, got %v", message)
+	}
+
+	// The connecting user's UID selects the namespace, and the server's own
+	// user is an admin
+	sessions := ut.server.Sessions()
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	if sessions[0].Namespace != mcp.UIDNamespace(uint32(os.Getuid())) || !sessions[0].Admin || sessions[0].Ephemeral {
+		t.Errorf("Expected an admin session in uid %d's namespace, got %+v", os.Getuid(), sessions[0])
+	}
+
+	// Hanging up closes the session
+	conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ut.server.Sessions()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the session to close with the connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUnixTransport_HTTP(t *testing.T) {
+	ut, err := NewUnixTransport(filepath.Join(t.TempDir(), "mcp.sock"), 0o600, SocketProtocolHTTP)
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	path := serveUnix(t, ut)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	defer client.CloseIdleConnections()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`
+	req, _ := http.NewRequest("POST", "http://unix/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Mcp-Session-Id") == "" {
+		t.Fatalf("Expected a new session, got %d", resp.StatusCode)
+	}
+
+	// Peer credentials stand in for a bearer token
+	sessions := ut.server.Sessions()
+	if len(sessions) != 1 || sessions[0].Namespace != mcp.UIDNamespace(uint32(os.Getuid())) {
+		t.Errorf("Expected a session in uid %d's namespace, got %+v", os.Getuid(), sessions)
+	}
+}
+
+func TestUnixTransport_AllowUIDs(t *testing.T) {
+	ut, err := NewUnixTransport(filepath.Join(t.TempDir(), "mcp.sock"), 0o666, SocketProtocolLines)
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	ut.AllowUIDs(uint32(os.Getuid()) + 1)
+	path := serveUnix(t, ut)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n"))
+	if reply, err := bufio.NewReader(conn).ReadBytes('\n'); err == nil {
+		t.Errorf("Expected a user not allowed to be hung up on, got %q", reply)
+	}
+}
+
+func TestUnixTransport_Listen(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := NewUnixTransport(filepath.Join(dir, "mcp.sock"), 0o600, "grpc"); err == nil {
+		t.Error("Expected an unknown protocol to be rejected")
+	}
+
+	// A regular file is never replaced
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0o600)
+	ut, _ := NewUnixTransport(file, 0o600, SocketProtocolLines)
+	if _, err := ut.Listen(); err == nil {
+		t.Error("Expected a regular file to be left alone")
+	}
+
+	// A socket in use is not taken over, but a stale one is replaced
+	path := filepath.Join(dir, "mcp.sock")
+	ut, _ = NewUnixTransport(path, 0o600, SocketProtocolLines)
+	listener, err := ut.Listen()
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	other, _ := NewUnixTransport(path, 0o600, SocketProtocolLines)
+	if _, err := other.Listen(); err == nil {
+		t.Error("Expected a socket in use to be refused")
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected a stale socket to remain: %v", err)
+	}
+	listener, err = other.Listen()
+	if err != nil {
+		t.Fatalf("Expected the stale socket to be replaced: %v", err)
+	}
+	listener.Close()
+}
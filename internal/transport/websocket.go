@@ -0,0 +1,355 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"favorite-colors-mcp/internal/mcp"
+	"favorite-colors-mcp/internal/websocket"
+)
+
+const (
+	// wsSubprotocol is the WebSocket subprotocol of MCP
+	wsSubprotocol = "mcp"
+	// wsPingInterval is how often idle connections are pinged
+	wsPingInterval = 30 * time.Second
+	// wsPongWait is how long a connection may stay silent, pongs included,
+	// before it is considered dead
+	wsPongWait = 2 * wsPingInterval
+	// wsMaxInFlight bounds the messages of a connection handled at once
+	wsMaxInFlight = 16
+	// wsTokenProtocolPrefix starts the subprotocol that carries a bearer
+	// token, encoded as unpadded base64url, in place of a header
+	wsTokenProtocolPrefix = "bearer."
+)
+
+// WebSocketTransport serves MCP over WebSocket. Each connection carries
+// JSON-RPC messages as text frames in both directions and is a session of
+// its own, to which the server can push notifications at any time.
+type WebSocketTransport struct {
+	server   *mcp.Server
+	port     string
+	useTLS   bool
+	certFile string
+	keyFile  string
+	auth     *TokenAuthenticator
+	upgrader websocket.Upgrader
+
+	connsMutex sync.Mutex
+	conns      map[*websocket.Conn]struct{}
+}
+
+// NewWebSocketTransport creates a new WebSocket transport
+func NewWebSocketTransport(port string, useTLS bool, certFile, keyFile string) *WebSocketTransport {
+	return NewWebSocketTransportWithServer(mcp.NewServer(), port, useTLS, certFile, keyFile)
+}
+
+// NewWebSocketTransportWithServer creates a new WebSocket transport serving
+// the given server
+func NewWebSocketTransportWithServer(server *mcp.Server, port string, useTLS bool, certFile, keyFile string) *WebSocketTransport {
+	return &WebSocketTransport{
+		server:   server,
+		port:     port,
+		useTLS:   useTLS,
+		certFile: certFile,
+		keyFile:  keyFile,
+		upgrader: websocket.Upgrader{Subprotocols: []string{wsSubprotocol}},
+		conns:    make(map[*websocket.Conn]struct{}),
+	}
+}
+
+// SetAuthenticator requires every connection to carry a bearer token known
+// to auth. Each authenticated principal gets its own favorites namespace.
+func (wt *WebSocketTransport) SetAuthenticator(auth *TokenAuthenticator) {
+	wt.auth = auth
+}
+
+// SetAllowedOrigins lets web pages from origins, such as
+// "https://tools.example.com", connect in addition to those served by the
+// transport's own host. "*" allows pages from any origin.
+func (wt *WebSocketTransport) SetAllowedOrigins(origins ...string) {
+	wt.upgrader.AllowedOrigins = origins
+}
+
+// handler routes the endpoints of the transport
+func (wt *WebSocketTransport) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", wt.handleWebSocket)
+	return mux
+}
+
+// Run starts the WebSocket transport server
+func (wt *WebSocketTransport) Run() error {
+	httpServer := &http.Server{
+		Addr:              wt.port,
+		Handler:           wt.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// Upgraded connections are not tracked by the HTTP server
+	httpServer.RegisterOnShutdown(wt.closeConns)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		scheme := "ws"
+		if wt.useTLS {
+			scheme = "wss"
+		}
+
+		log.Printf("Favorite Colors MCP Server starting on %s://localhost%s/mcp", scheme, wt.port)
+		log.Println("Transport: WebSocket (subprotocol \"mcp\")")
+		log.Println("Available tools: add_color, import_colors, get_colors, remove_color, clear_colors")
+		log.Println("Press CTRL+C to shutdown gracefully...")
+
+		var err error
+		if wt.useTLS {
+			err = httpServer.ListenAndServeTLS(wt.certFile, wt.keyFile)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	<-quit
+	log.Println()
+	log.Println("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("server forced to shutdown: %w", err)
+	}
+
+	log.Println("Server shutdown gracefully")
+	return nil
+}
+
+// authenticate checks the bearer token of a connection request. Browsers
+// cannot set headers on WebSocket requests, so the token may also be
+// offered as a "bearer.<token>" subprotocol next to "mcp", or as a last
+// resort come as the access_token query parameter, which leaves it in
+// proxy logs and browser history.
+func (wt *WebSocketTransport) authenticate(w http.ResponseWriter, r *http.Request) (*Principal, bool) {
+	if wt.auth == nil {
+		return nil, true
+	}
+	principal, ok := wt.auth.Authenticate(r)
+	if !ok {
+		principal, ok = wt.auth.AuthenticateToken(protocolToken(r))
+	}
+	if !ok {
+		principal, ok = wt.auth.AuthenticateToken(r.URL.Query().Get("access_token"))
+	}
+	if !ok {
+		unauthorized(w)
+		return nil, false
+	}
+	return &principal, true
+}
+
+// protocolToken returns the bearer token offered as a subprotocol, or ""
+func protocolToken(r *http.Request) string {
+	for _, protocol := range websocket.Subprotocols(r) {
+		encoded, ok := strings.CutPrefix(protocol, wsTokenProtocolPrefix)
+		if !ok {
+			continue
+		}
+		if token, err := base64.RawURLEncoding.DecodeString(encoded); err == nil {
+			return string(token)
+		}
+	}
+	return ""
+}
+
+// handleWebSocket upgrades a request and serves the connection's session
+func (wt *WebSocketTransport) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	principal, ok := wt.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	session := &mcp.Session{}
+	if principal != nil {
+		session.Namespace = principal.namespace()
+		session.Admin = principal.Admin
+	} else {
+		sessionID, err := newSessionID()
+		if err != nil {
+			log.Printf("Error generating session ID: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		session.Namespace = mcp.SessionNamespace(sessionID)
+		session.Ephemeral = true
+	}
+
+	conn, err := wt.upgrader.Upgrade(w, r)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	wt.connsMutex.Lock()
+	wt.conns[conn] = struct{}{}
+	wt.connsMutex.Unlock()
+	defer func() {
+		wt.connsMutex.Lock()
+		delete(wt.conns, conn)
+		wt.connsMutex.Unlock()
+	}()
+
+	log.Printf("WebSocket connection opened from %s", conn.RemoteAddr())
+	wt.serveConn(r.Context(), conn, wt.server.OpenSession(session))
+	log.Printf("WebSocket connection closed from %s", conn.RemoteAddr())
+}
+
+// serveConn handles the messages of a connection until it closes, then
+// cancels the requests left in flight, which no one awaits anymore, and
+// closes its session
+func (wt *WebSocketTransport) serveConn(ctx context.Context, conn *websocket.Conn, session *mcp.Session) {
+	ctx, cancel := context.WithCancel(ctx)
+	send := func(msg interface{}) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}
+	session.SetSender(func(msg mcp.JSONRPCRequest) error {
+		return send(msg)
+	})
+	reply := func(msg interface{}) {
+		if err := send(msg); err != nil && err != websocket.ErrClosed {
+			log.Printf("Error writing response: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	var handlers sync.WaitGroup
+	defer func() {
+		close(done)
+		cancel()
+		handlers.Wait()
+		if err := wt.server.CloseSession(session); err != nil {
+			log.Printf("Error closing session: %v", err)
+		}
+	}()
+
+	// Keepalive: the client must answer pings, or send something, in time
+	alive := func() {
+		if err := conn.SetReadDeadline(time.Now().Add(wsPongWait)); err != nil {
+			log.Printf("Error setting read deadline: %v", err)
+		}
+	}
+	alive()
+	conn.SetPongHandler(func([]byte) { alive() })
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := conn.Ping(nil); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	inFlight := make(chan struct{}, wsMaxInFlight)
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormal && closeErr.Code != websocket.CloseGoingAway {
+				log.Printf("WebSocket connection ended: %v", err)
+			}
+			_ = conn.Close(websocket.CloseNormal, "")
+			return
+		}
+		alive()
+
+		if messageType != websocket.TextMessage {
+			_ = conn.Close(websocket.CloseUnsupportedData, "JSON-RPC messages must be text")
+			return
+		}
+
+		msg, rpcErr := mcp.ParseMessage(data)
+		if rpcErr != nil {
+			log.Printf("Error parsing request: %s", rpcErr.Message)
+			reply(mcp.JSONRPCResponse{JSONRPC: "2.0", Error: rpcErr})
+			continue
+		}
+		// Notifications and initialize are handled in the order they are
+		// read, as the lifecycle and cancellations depend on it
+		if !msg.NeedsReply() || msg.Method() == "initialize" {
+			if response := msg.Reply(wt.server.HandleSessionMessage(ctx, session, msg)); response != nil {
+				reply(response)
+			}
+			continue
+		}
+
+		// Past the limit, requests are refused rather than left to hold up
+		// the notifications read behind them
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			reply(msg.Refuse(errServerBusy(wsMaxInFlight)))
+			continue
+		}
+
+		// Requests are handled concurrently, so a slow tool call does not
+		// hold up the rest of the connection. They are in flight from here,
+		// so a cancellation read next reaches them.
+		handle := wt.server.StartSessionMessage(ctx, session, msg)
+		handlers.Add(1)
+		go func() {
+			defer func() {
+				<-inFlight
+				handlers.Done()
+			}()
+			if response := msg.Reply(handle()); response != nil {
+				reply(response)
+			}
+		}()
+	}
+}
+
+// closeConns closes every connection, as when the server shuts down
+func (wt *WebSocketTransport) closeConns() {
+	wt.connsMutex.Lock()
+	defer wt.connsMutex.Unlock()
+	for conn := range wt.conns {
+		_ = conn.Close(websocket.CloseGoingAway, "server shutting down")
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"favorite-colors-mcp/internal/mcp"
+)
+
+// wsClient is a minimal WebSocket client speaking text frames
+type wsClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocket connects to the transport's endpoint, returning the
+// handshake response and, if it succeeded, the client
+func dialWebSocket(t *testing.T, srv *httptest.Server, target string, header http.Header) (*wsClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	req, _ := http.NewRequest("GET", srv.URL+target, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Protocol", "mcp")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	return &wsClient{conn: conn, reader: reader}, resp
+}
+
+// send writes a masked text frame
+func (c *wsClient) send(t *testing.T, message string) {
+	t.Helper()
+	frame := []byte{0x81}
+	if len(message) <= 125 {
+		frame = append(frame, 0x80|byte(len(message)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(message)))
+	}
+	frame = append(frame, 0, 0, 0, 0) // a zero mask leaves the payload as is
+	frame = append(frame, message...)
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+}
+
+// receive returns the next text message, skipping control frames
+func (c *wsClient) receive(t *testing.T) map[string]interface{} {
+	t.Helper()
+	if err := c.conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		length := int(header[1] & 0x7F)
+		if length == 126 {
+			var ext [2]byte
+			io.ReadFull(c.reader, ext[:])
+			length = int(binary.BigEndian.Uint16(ext[:]))
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			t.Fatalf("Failed to read payload: %v", err)
+		}
+		if header[0]&0x0F != 0x1 {
+			continue
+		}
+
+		var message map[string]interface{}
+		if err := json.Unmarshal(payload, &message); err != nil {
+			t.Fatalf("Expected a JSON message, got %q", payload)
+		}
+		return message
+	}
+}
+
+func TestWebSocketTransport_Session(t *testing.T) {
+	wt := NewWebSocketTransport(":0", false, "", "")
+	srv := httptest.NewServer(wt.handler())
+	defer srv.Close()
+
+	c, resp := dialWebSocket(t, srv, "/mcp", nil)
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Protocol") != "mcp" {
+		t.Fatalf("Expected the mcp subprotocol, got %d %q", resp.StatusCode, resp.Header.Get("Sec-WebSocket-Protocol"))
+	}
+
+	// The connection is a session, which must be initialized first
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_colors"}}`)
+	if message := c.receive(t); message["error"] == nil {
+		t.Errorf("Expected calls before initialize to fail, got %v", message)
+	}
+
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	if message := c.receive(t); message["id"] != float64(2) || message["result"] == nil {
+		t.Fatalf("Expected the initialize result, got %v", message)
+	}
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	c.send(t, `{"jsonrpc":"2.0","id":"add","method":"tools/call","params":{"name":"add_color","arguments":{"color":"blue"}}}`)
+	if message := c.receive(t); message["id"] != "add" || !strings.Contains(mustJSON(t, message), "blue") {
+		t.Errorf("Expected the add_color result, got %v", message)
+	}
+
+	// The server can push to the connection at any time
+	sessions := wt.server.Sessions()
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	if err := sessions[0].Notify("notifications/test", map[string]interface{}{"hello": "ws"}); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if message := c.receive(t); message["method"] != "notifications/test" || message["id"] != nil {
+		t.Errorf("Expected the pushed notification, got %v", message)
+	}
+
+	// Closing the connection closes the session
+	c.conn.Write([]byte{0x88, 0x82, 0, 0, 0, 0, 0x03, 0xE8})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(wt.server.Sessions()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the session to close with the connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !sessions[0].Closed() {
+		t.Error("Expected the session to be closed")
+	}
+}
+
+func TestWebSocketTransport_Cancellation(t *testing.T) {
+	server := mcp.NewServer()
+	cancelled := make(chan struct{})
+	server.RegisterTool(mcp.Tool{Name: "block", InputSchema: mcp.ToolSchema{Type: "object"}},
+		func(ctx context.Context, args map[string]interface{}) (*mcp.ToolResult, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return &mcp.ToolResult{Text: "too late"}, nil
+		})
+	wt := NewWebSocketTransportWithServer(server, ":0", false, "", "")
+	srv := httptest.NewServer(wt.handler())
+	defer srv.Close()
+
+	c, _ := dialWebSocket(t, srv, "/mcp", nil)
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	c.receive(t)
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	// The cancellation is read right behind the call, likely before its
+	// handler runs, and must reach it all the same
+	c.send(t, `{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"block"}}`)
+	c.send(t, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow"}}`)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the call to be cancelled")
+	}
+
+	// The cancelled call is not answered, so the next reply is the ping's
+	c.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if message := c.receive(t); message["id"] != float64(2) {
+		t.Errorf("Expected only the ping answered, got %v", message)
+	}
+}
+
+func TestWebSocketTransport_Authentication(t *testing.T) {
+	wt := NewWebSocketTransport(":0", false, "", "")
+	wt.SetAuthenticator(NewTokenAuthenticator(map[string]Principal{
+		"alice-token": {Name: "alice"},
+	}))
+	srv := httptest.NewServer(wt.handler())
+	defer srv.Close()
+
+	if _, resp := dialWebSocket(t, srv, "/mcp", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", resp.StatusCode)
+	}
+	if _, resp := dialWebSocket(t, srv, "/mcp", http.Header{"Authorization": {"Bearer alice-token"}}); resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected the header token to be accepted, got %d", resp.StatusCode)
+	}
+
+	// Browsers offer the token as a subprotocol, which is never selected
+	offer := http.Header{"Sec-WebSocket-Protocol": {"mcp, bearer." + base64.RawURLEncoding.EncodeToString([]byte("alice-token"))}}
+	_, resp := dialWebSocket(t, srv, "/mcp", offer)
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Protocol") != "mcp" {
+		t.Errorf("Expected the subprotocol token to be accepted with mcp selected, got %d %q", resp.StatusCode, resp.Header.Get("Sec-WebSocket-Protocol"))
+	}
+	offer = http.Header{"Sec-WebSocket-Protocol": {"mcp, bearer." + base64.RawURLEncoding.EncodeToString([]byte("mallory-token"))}}
+	if _, resp := dialWebSocket(t, srv, "/mcp", offer); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown subprotocol token, got %d", resp.StatusCode)
+	}
+
+	// or, as a last resort, in the URL
+	c, resp := dialWebSocket(t, srv, "/mcp?access_token=alice-token", nil)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected the query token to be accepted, got %d", resp.StatusCode)
+	}
+	c.send(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	c.receive(t)
+	for _, sess := range wt.server.Sessions() {
+		if sess.Namespace != mcp.PrincipalNamespace("alice") || sess.Ephemeral {
+			t.Errorf("Expected alice's namespace, got %q", sess.Namespace)
+		}
+	}
+}
+
+func TestWebSocketTransport_AllowedOrigins(t *testing.T) {
+	wt := NewWebSocketTransport(":0", false, "", "")
+	srv := httptest.NewServer(wt.handler())
+	defer srv.Close()
+
+	page := http.Header{"Origin": {"https://tools.example.com"}}
+	if _, resp := dialWebSocket(t, srv, "/mcp", page); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a page of another origin, got %d", resp.StatusCode)
+	}
+	wt.SetAllowedOrigins("https://tools.example.com")
+	if _, resp := dialWebSocket(t, srv, "/mcp", page); resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected the allowed origin to connect, got %d", resp.StatusCode)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	return string(data)
+}
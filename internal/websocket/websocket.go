@@ -0,0 +1,546 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package websocket implements the server side of the WebSocket protocol
+// (RFC 6455).
+//
+// It covers what a JSON-RPC transport needs: the opening handshake with
+// subprotocol selection and origin checks, text and binary messages,
+// fragmentation, ping and pong, and the closing handshake. Extensions such
+// as compression are not supported and are never negotiated.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1" // #nosec G505 -- required by the handshake, not used for security
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// acceptGUID is appended to the client's key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes of frames
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// MessageType is the type of a data message
+type MessageType int
+
+// Types of data messages
+const (
+	TextMessage   MessageType = opText
+	BinaryMessage MessageType = opBinary
+)
+
+// Status codes sent in close frames
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// DefaultReadLimit is the largest message a connection accepts unless
+// changed with SetReadLimit
+const DefaultReadLimit = 4 << 20
+
+// writeTimeout bounds how long a frame may take to write
+const writeTimeout = 10 * time.Second
+
+// ErrClosed is returned when writing to a connection after it was closed
+var ErrClosed = errors.New("websocket: connection closed")
+
+// CloseError is returned by ReadMessage once the peer has closed the
+// connection, or the connection was closed for a protocol violation
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: closed with status %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with status %d: %s", e.Code, e.Reason)
+}
+
+// HandshakeError is returned by Upgrade for requests that are not valid
+// WebSocket handshakes. Upgrade has already answered them.
+type HandshakeError struct {
+	Reason string
+}
+
+func (e *HandshakeError) Error() string {
+	return "websocket: bad handshake: " + e.Reason
+}
+
+// Conn is a server-side WebSocket connection. ReadMessage must only be
+// called from one goroutine at a time; writes may come from any number.
+type Conn struct {
+	conn        net.Conn
+	reader      *bufio.Reader
+	subprotocol string
+	readLimit   int64
+
+	writeMutex sync.Mutex
+	closed     bool
+
+	pongMutex   sync.Mutex
+	pongHandler func(data []byte)
+}
+
+// Upgrader performs opening handshakes
+type Upgrader struct {
+	// Subprotocols are the subprotocols the server speaks. The first of the
+	// client's subprotocols that is also one of these is selected; a client
+	// offering subprotocols but none of these is refused.
+	Subprotocols []string
+	// AllowedOrigins are the origins, such as "https://tools.example.com",
+	// of the web pages allowed to connect, or "*" for any. Pages served by
+	// the host the request is addressed to are always allowed, as are
+	// clients that send no Origin, which are not browsers.
+	AllowedOrigins []string
+}
+
+// Upgrade performs the opening handshake on an HTTP request with the
+// given subprotocols, only allowing web pages of the same host, and takes
+// over its connection
+func Upgrade(w http.ResponseWriter, r *http.Request, subprotocols ...string) (*Conn, error) {
+	u := &Upgrader{Subprotocols: subprotocols}
+	return u.Upgrade(w, r)
+}
+
+// Subprotocols returns the subprotocols a handshake request offers, in the
+// client's order of preference
+func Subprotocols(r *http.Request) []string {
+	return headerTokens(r.Header, "Sec-WebSocket-Protocol")
+}
+
+// Upgrade performs the opening handshake on an HTTP request and takes over
+// its connection. Invalid handshakes, and requests from web pages whose
+// origin is not allowed, are answered with an HTTP error.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	fail := func(status int, reason string) (*Conn, error) {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, reason, status)
+		return nil, &HandshakeError{Reason: reason}
+	}
+
+	if r.Method != "GET" {
+		return fail(http.StatusMethodNotAllowed, "method must be GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusUpgradeRequired, "not a websocket upgrade")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+
+	if !u.originAllowed(r) {
+		return fail(http.StatusForbidden, "origin not allowed")
+	}
+
+	subprotocol := ""
+	offered := Subprotocols(r)
+	for _, candidate := range offered {
+		if subprotocol == "" && contains(u.Subprotocols, candidate) {
+			subprotocol = candidate
+		}
+	}
+	if len(offered) > 0 && subprotocol == "" {
+		return fail(http.StatusBadRequest, "no supported subprotocol")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "connection cannot be upgraded")
+	}
+	// The server's read and write timeouts no longer apply
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	if _, err := rw.WriteString(response + "\r\n"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:        conn,
+		reader:      rw.Reader,
+		subprotocol: subprotocol,
+		readLimit:   DefaultReadLimit,
+	}, nil
+}
+
+// originAllowed reports whether the web page a request comes from, if any,
+// may connect. Browsers let any page open a WebSocket to any host, so
+// without this check a page could act for the user on a server it reaches.
+func (u *Upgrader) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range u.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host != "" && strings.EqualFold(parsed.Host, r.Host)
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for a client key
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID)) // #nosec G401
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Subprotocol returns the subprotocol selected by the handshake, if any
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the address of the peer
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets the largest message ReadMessage accepts. Larger ones
+// close the connection.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline sets when a blocked ReadMessage fails. Keepalives extend
+// it from the pong handler.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetPongHandler sets a function called with the payload of each pong
+func (c *Conn) SetPongHandler(handler func(data []byte)) {
+	c.pongMutex.Lock()
+	defer c.pongMutex.Unlock()
+	c.pongHandler = handler
+}
+
+// ReadMessage returns the next data message. Control frames are handled
+// as they arrive: pings are answered and pongs passed to the pong handler.
+// Once the peer closes the connection, or breaks the protocol, it returns
+// a *CloseError and the connection is closed.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var (
+		messageType MessageType
+		message     []byte
+		fragmented  bool
+	)
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			c.pongMutex.Lock()
+			handler := c.pongHandler
+			c.pongMutex.Unlock()
+			if handler != nil {
+				handler(payload)
+			}
+			continue
+		case opClose:
+			return 0, nil, c.closeReceived(payload)
+		case opText, opBinary:
+			if fragmented {
+				return 0, nil, c.fail(&CloseError{Code: CloseProtocolError, Reason: "expected continuation frame"})
+			}
+			messageType = MessageType(opcode)
+			message = payload
+		case opContinuation:
+			if !fragmented {
+				return 0, nil, c.fail(&CloseError{Code: CloseProtocolError, Reason: "unexpected continuation frame"})
+			}
+			if int64(len(message)+len(payload)) > c.readLimit {
+				return 0, nil, c.fail(&CloseError{Code: CloseMessageTooBig, Reason: "message too big"})
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, c.fail(&CloseError{Code: CloseProtocolError, Reason: fmt.Sprintf("unknown opcode %d", opcode)})
+		}
+
+		fragmented = !fin
+		if fragmented {
+			continue
+		}
+		if messageType == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(&CloseError{Code: CloseInvalidPayload, Reason: "invalid UTF-8"})
+		}
+		return messageType, message, nil
+	}
+}
+
+// readFrame reads one frame and unmasks its payload
+func (c *Conn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, &CloseError{Code: CloseProtocolError, Reason: "reserved bits set"}
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, &CloseError{Code: CloseProtocolError, Reason: "client frames must be masked"}
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= opClose && (length > 125 || !fin) {
+		return false, 0, nil, &CloseError{Code: CloseProtocolError, Reason: "invalid control frame"}
+	}
+	if length > uint64(c.readLimit) {
+		return false, 0, nil, &CloseError{Code: CloseMessageTooBig, Reason: "message too big"}
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// closeReceived answers the peer's close frame and closes the connection.
+// Close frames with an invalid status fail the connection instead.
+func (c *Conn) closeReceived(payload []byte) error {
+	switch {
+	case len(payload) == 0:
+		_ = c.close(CloseNormal, "")
+		return &CloseError{Code: CloseNoStatus}
+	case len(payload) == 1:
+		return c.fail(&CloseError{Code: CloseProtocolError, Reason: "invalid close frame"})
+	}
+
+	code := int(binary.BigEndian.Uint16(payload))
+	if !validCloseCode(code) {
+		return c.fail(&CloseError{Code: CloseProtocolError, Reason: fmt.Sprintf("invalid close code %d", code)})
+	}
+	if !utf8.Valid(payload[2:]) {
+		return c.fail(&CloseError{Code: CloseProtocolError, Reason: "invalid close reason"})
+	}
+
+	// Echo the status, as the closing handshake asks
+	_ = c.close(code, "")
+	return &CloseError{Code: code, Reason: string(payload[2:])}
+}
+
+// validCloseCode reports whether a peer may send code in a close frame:
+// one of the codes defined for the protocol, or one left to libraries and
+// applications. Codes such as 1005 and 1006 only stand for missing status
+// and must never be sent (RFC 6455, section 7.4).
+func validCloseCode(code int) bool {
+	switch {
+	case code >= CloseNormal && code <= CloseUnsupportedData:
+		return true
+	case code >= CloseInvalidPayload && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// fail closes the connection after a read error. Protocol violations are
+// reported to the peer in the close frame.
+func (c *Conn) fail(err error) error {
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		_ = c.close(closeErr.Code, closeErr.Reason)
+		return closeErr
+	}
+	_ = c.conn.Close()
+	return err
+}
+
+// WriteMessage sends a data message in a single frame
+func (c *Conn) WriteMessage(messageType MessageType, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// Ping sends a ping, which the peer answers with a pong carrying data
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > 125 {
+		return errors.New("websocket: ping payload too long")
+	}
+	return c.writeFrame(opPing, data)
+}
+
+// Close sends a close frame with code and reason and closes the connection
+// without waiting for the peer's answer
+func (c *Conn) Close(code int, reason string) error {
+	return c.close(code, reason)
+}
+
+func (c *Conn) close(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+
+	err := c.writeFrame(opClose, payload)
+	c.writeMutex.Lock()
+	c.closed = true
+	c.writeMutex.Unlock()
+	if closeErr := c.conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err == ErrClosed {
+		return nil
+	}
+	return err
+}
+
+// writeFrame writes a single unmasked frame
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+
+	frame := make([]byte, 0, 10+len(payload))
+	frame = append(frame, 0x80|opcode)
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	frame = append(frame, payload...)
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// headerContains reports whether a comma-separated header has token,
+// ignoring case
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range headerTokens(header, name) {
+		if strings.EqualFold(value, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerTokens splits the comma-separated values of a header
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
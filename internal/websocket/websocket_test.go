@@ -0,0 +1,350 @@
+// Copyright 2025 Favorite Colors MCP Server
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// client is a minimal WebSocket client for exercising the server
+type client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, url string, header http.Header) (*client, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	return &client{conn: conn, reader: reader}, resp
+}
+
+// write sends a masked frame
+func (c *client) write(t *testing.T, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatalf("Failed to write frame: %v", err)
+	}
+}
+
+// read returns the next frame from the server
+func (c *client) read(t *testing.T) (byte, []byte) {
+	t.Helper()
+	if err := c.conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("Expected server frames to be unmasked")
+	}
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.reader, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.reader, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+// expectClose reads a close frame with the given status
+func (c *client) expectClose(t *testing.T, code int) {
+	t.Helper()
+	opcode, payload := c.read(t)
+	if opcode != opClose || len(payload) < 2 || int(binary.BigEndian.Uint16(payload)) != code {
+		t.Errorf("Expected close %d, got opcode %d %q", code, opcode, payload)
+	}
+}
+
+// echoServer echoes messages until the connection closes, then reports the
+// error that ended it
+func echoServer(t *testing.T, limit int64) (string, <-chan error) {
+	t.Helper()
+	done := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, "mcp")
+		if err != nil {
+			return
+		}
+		if limit > 0 {
+			conn.SetReadLimit(limit)
+		}
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				done <- err
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, done
+}
+
+func TestUpgrade_Handshake(t *testing.T) {
+	url, _ := echoServer(t, 0)
+
+	_, resp := dial(t, url, http.Header{"Sec-Websocket-Protocol": {"other, mcp"}})
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	// The example of RFC 6455 section 1.3
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected the RFC 6455 accept key, got %q", accept)
+	}
+	if protocol := resp.Header.Get("Sec-WebSocket-Protocol"); protocol != "mcp" {
+		t.Errorf("Expected the mcp subprotocol, got %q", protocol)
+	}
+}
+
+func TestUpgrade_BadHandshakes(t *testing.T) {
+	url, _ := echoServer(t, 0)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"version", http.Header{"Sec-Websocket-Version": {"8"}}, http.StatusUpgradeRequired},
+		{"key", http.Header{"Sec-Websocket-Key": {"short"}}, http.StatusBadRequest},
+		{"upgrade", http.Header{"Upgrade": {"h2c"}}, http.StatusUpgradeRequired},
+		{"subprotocol", http.Header{"Sec-Websocket-Protocol": {"graphql-ws"}}, http.StatusBadRequest},
+		{"origin", http.Header{"Origin": {"https://attacker.example"}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp := dial(t, url, tt.header)
+			if resp.StatusCode != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestUpgrader_AllowedOrigins(t *testing.T) {
+	u := &Upgrader{AllowedOrigins: []string{"https://tools.example.com"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := u.Upgrade(w, r); err == nil {
+			conn.Close(CloseNormal, "")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		origin string
+		want   int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{"https://tools.example.com", http.StatusSwitchingProtocols},
+		{"HTTPS://Tools.Example.com", http.StatusSwitchingProtocols},
+		{"http://" + strings.TrimPrefix(srv.URL, "http://"), http.StatusSwitchingProtocols},
+		{"https://other.example.com", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		if _, resp := dial(t, srv.URL, header); resp.StatusCode != tt.want {
+			t.Errorf("Origin %q: expected %d, got %d", tt.origin, tt.want, resp.StatusCode)
+		}
+	}
+}
+
+func TestConn_CloseCodes(t *testing.T) {
+	url, done := echoServer(t, 0)
+	c, _ := dial(t, url, nil)
+
+	// Codes left to applications are echoed like the protocol's own
+	c.write(t, true, opClose, []byte{0x0F, 0xA0})
+	c.expectClose(t, 4000)
+	var closeErr *CloseError
+	if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != 4000 {
+		t.Errorf("Expected the peer's close, got %v", err)
+	}
+}
+
+func TestConn_Messages(t *testing.T) {
+	url, done := echoServer(t, 0)
+	c, _ := dial(t, url, nil)
+
+	c.write(t, true, opText, []byte("hello"))
+	if opcode, payload := c.read(t); opcode != opText || string(payload) != "hello" {
+		t.Errorf("Expected hello echoed, got %d %q", opcode, payload)
+	}
+
+	// Long messages use extended lengths both ways
+	long := strings.Repeat("x", 70000)
+	c.write(t, true, opBinary, []byte(long))
+	if opcode, payload := c.read(t); opcode != opBinary || string(payload) != long {
+		t.Errorf("Expected the long message echoed, got %d and %d bytes", opcode, len(payload))
+	}
+
+	// Fragments are reassembled, with control frames in between
+	c.write(t, false, opText, []byte("frag"))
+	c.write(t, true, opPing, []byte("p"))
+	c.write(t, true, opContinuation, []byte("ment"))
+	if opcode, payload := c.read(t); opcode != opPong || string(payload) != "p" {
+		t.Errorf("Expected the ping answered, got %d %q", opcode, payload)
+	}
+	if opcode, payload := c.read(t); opcode != opText || string(payload) != "fragment" {
+		t.Errorf("Expected fragment echoed, got %d %q", opcode, payload)
+	}
+
+	c.write(t, true, opClose, []byte{0x03, 0xE8, 'b', 'y', 'e'})
+	c.expectClose(t, CloseNormal)
+	var closeErr *CloseError
+	if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != CloseNormal || closeErr.Reason != "bye" {
+		t.Errorf("Expected the peer's close, got %v", err)
+	}
+}
+
+func TestConn_ProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		send  func(t *testing.T, c *client)
+		limit int64
+		want  int
+	}{
+		{"unmasked", func(t *testing.T, c *client) { c.conn.Write([]byte{0x81, 0x01, 'x'}) }, 0, CloseProtocolError},
+		{"reserved bits", func(t *testing.T, c *client) { c.write(t, true, 0x40|opText, []byte("x")) }, 0, CloseProtocolError},
+		{"unknown opcode", func(t *testing.T, c *client) { c.write(t, true, 0x3, nil) }, 0, CloseProtocolError},
+		{"stray continuation", func(t *testing.T, c *client) { c.write(t, true, opContinuation, []byte("x")) }, 0, CloseProtocolError},
+		{"fragmented ping", func(t *testing.T, c *client) { c.write(t, false, opPing, nil) }, 0, CloseProtocolError},
+		{"invalid UTF-8", func(t *testing.T, c *client) { c.write(t, true, opText, []byte{0xff, 0xfe}) }, 0, CloseInvalidPayload},
+		{"too big", func(t *testing.T, c *client) { c.write(t, true, opText, make([]byte, 100)) }, 10, CloseMessageTooBig},
+		{"no status code sent", func(t *testing.T, c *client) { c.write(t, true, opClose, []byte{0x03, 0xED}) }, 0, CloseProtocolError},
+		{"abnormal code sent", func(t *testing.T, c *client) { c.write(t, true, opClose, []byte{0x03, 0xEE}) }, 0, CloseProtocolError},
+		{"TLS code sent", func(t *testing.T, c *client) { c.write(t, true, opClose, []byte{0x03, 0xF7}) }, 0, CloseProtocolError},
+		{"unassigned code", func(t *testing.T, c *client) { c.write(t, true, opClose, []byte{0x07, 0xD0}) }, 0, CloseProtocolError},
+		{"close reason", func(t *testing.T, c *client) { c.write(t, true, opClose, []byte{0x03, 0xE8, 0xff}) }, 0, CloseProtocolError},
+		{"too big fragmented", func(t *testing.T, c *client) {
+			c.write(t, false, opText, make([]byte, 8))
+			c.write(t, true, opContinuation, make([]byte, 8))
+		}, 10, CloseMessageTooBig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, done := echoServer(t, tt.limit)
+			c, _ := dial(t, url, nil)
+			tt.send(t, c)
+			c.expectClose(t, tt.want)
+			var closeErr *CloseError
+			if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != tt.want {
+				t.Errorf("Expected close %d, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestConn_PingPong(t *testing.T) {
+	pongs := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		conn.SetPongHandler(func(data []byte) { pongs <- string(data) })
+		if err := conn.Ping([]byte("keepalive")); err != nil {
+			t.Errorf("Failed to ping: %v", err)
+		}
+		conn.ReadMessage()
+	}))
+	defer srv.Close()
+
+	c, _ := dial(t, srv.URL, nil)
+	opcode, payload := c.read(t)
+	if opcode != opPing || string(payload) != "keepalive" {
+		t.Fatalf("Expected a ping, got %d %q", opcode, payload)
+	}
+	c.write(t, true, opPong, payload)
+
+	select {
+	case data := <-pongs:
+		if data != "keepalive" {
+			t.Errorf("Expected the pong payload, got %q", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the pong handler to run")
+	}
+}